@@ -0,0 +1,98 @@
+package dfa
+
+// addAllow 新增白名单短语，短语与敏感词使用同一套归一化规则，
+// 短语中汉字的读音同样需要分配字符，否则之后新增的读音会改变短语的归一化结果
+func (tree *TrieTree) addAllow(word string) {
+	tree.addSyllables(word)
+	cur := tree.addLiteral(tree.allowRoot, word)
+	if cur == nil {
+		return
+	}
+	cur.isEnd = true
+	cur.word = word
+	cur.refs++
+}
+
+// removeAllow 删除白名单短语，不存在时返回 false
+func (tree *TrieTree) removeAllow(word string) bool {
+	path := tree.findLiteral(tree.allowRoot, word)
+	if len(path) == 0 {
+		return false
+	}
+	cur := path[len(path)-1]
+	if cur.isRoot || !cur.isEnd || cur.word != word {
+		return false
+	}
+	cur.refs--
+	if cur.refs == 0 {
+		cur.unsetEnd()
+		prunePath(path)
+	}
+	return true
+}
+
+// suppression 被白名单短语完整覆盖的命中
+type suppression struct {
+	hit
+	allow hit
+}
+
+// suppress 过滤被白名单短语完整覆盖的命中，allows 需按起始位置排序
+func suppress(hits, allows []hit) ([]hit, []suppression) {
+	if len(allows) == 0 {
+		return hits, nil
+	}
+
+	var (
+		kept       = hits[:0]
+		suppressed []suppression
+	)
+	for _, h := range hits {
+		if allow, ok := coveringAllow(allows, h); ok {
+			suppressed = append(suppressed, suppression{hit: h, allow: allow})
+			continue
+		}
+		kept = append(kept, h)
+	}
+	return kept, suppressed
+}
+
+func coveringAllow(allows []hit, h hit) (hit, bool) {
+	for _, allow := range allows {
+		if allow.start > h.start {
+			break
+		}
+		if allow.end >= h.end {
+			return allow, true
+		}
+	}
+	return hit{}, false
+}
+
+// reportSuppressed 记录抑制统计，并按需返回被抑制的命中
+func (tree *TrieTree) reportSuppressed(runes []rune, suppressed []suppression, o scanOptions) {
+	if len(suppressed) == 0 {
+		return
+	}
+
+	var (
+		text    string
+		offsets []int
+	)
+	if o.suppressed != nil {
+		text = string(runes)
+		offsets = byteOffsets(text)
+	}
+	for _, s := range suppressed {
+		s.allow.node.incrStats(tree.openStats)
+		if tree.openStats {
+			s.node.suppressedCount.Inc()
+		}
+		if o.suppressed != nil {
+			match := newMatch(text, offsets, s.hit)
+			match.Word = s.node.comboWord(match.Word)
+			match.Allow = s.allow.node.word
+			*o.suppressed = append(*o.suppressed, match)
+		}
+	}
+}
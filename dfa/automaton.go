@@ -0,0 +1,272 @@
+package dfa
+
+import "sort"
+
+// hit 一次命中，start/end 为命中的首尾字符在原文中的下标，first/last 为归一化后的下标（均为闭区间），
+// distance 为模糊匹配的编辑距离，精确命中为 0
+type hit struct {
+	node     *Node
+	start    int
+	end      int
+	first    int
+	last     int
+	distance int
+}
+
+// result 一次有效命中，组合词需所有片段均命中
+type result struct {
+	hit
+	combo []hit
+}
+
+// results 扫描文本，过滤掉被白名单短语覆盖的命中与未满足条件的组合词后按匹配策略筛选，同时返回归一化后的文本
+func (tree *TrieTree) results(runes []rune, o scanOptions) ([]result, []unit) {
+	units := tree.normalize(runes)
+	exact := tree.matches(tree.root, runes, units)
+	if tree.base != nil {
+		exact = sortHits(tree.live(append(exact, tree.base.matches(tree.base.root, runes, units)...)))
+	}
+	results, _ := tree.collect(runes, units, exact, nil, 0, len(runes), o)
+	return results, units
+}
+
+// collect 在精确命中的基础上补充其他命中并筛选，只保留结束位置在 [from, to) 内的命中，规则按最后出现的词判断。
+// pending 为此前其他片段尚未出现的组合词，与本次的命中一起重新检查，仍未满足的组合词一并返回。
+// 增量词库与主词库的命中合并后一起筛选，主词库中已经删除的词的命中不再返回
+func (tree *TrieTree) collect(runes []rune, units []unit, exact, pending []hit, from, to int, o scanOptions) ([]result, []hit) {
+	var (
+		layers     = tree.layers()
+		results    []result
+		unresolved []hit
+		// 白名单短语的命中
+		allows []hit
+		// 组合词片段的命中，存在组合词时才扫描且只扫描一次
+		comboHits []hit
+		comboScan bool
+	)
+	for _, layer := range layers {
+		if len(layer.allowRoot.children) > 0 {
+			allows = append(allows, layer.matches(layer.allowRoot, runes, units)...)
+		}
+	}
+	if len(layers) > 1 {
+		allows = sortHits(tree.live(allows))
+	}
+	scanCombo := func() []hit {
+		if !comboScan {
+			for _, layer := range layers {
+				comboHits = append(comboHits, layer.matches(layer.comboRoot, runes, units)...)
+			}
+			if len(layers) > 1 {
+				comboHits = sortHits(uniqueKeyHits(comboHits))
+			}
+			// 组合词片段、规则中的词被覆盖时视为未出现，不单独上报
+			comboHits, _ = suppress(comboHits, allows)
+			comboScan = true
+		}
+		return comboHits
+	}
+
+	hits := exact
+	for _, layer := range layers {
+		hits = append(hits, layer.matchFuzzy(runes, units, exact)...)
+		hits = append(hits, layer.matchPatterns(runes, units)...)
+		hits = append(hits, layer.matchRegexps(runes, units)...)
+	}
+	hits = inRange(sortHits(tree.live(hits)), from, to)
+	hits, suppressed := suppress(hits, allows)
+	tree.reportSuppressed(runes, suppressed, o)
+	for _, h := range append(hits, pending...) {
+		if !o.accept(h.node) {
+			continue
+		}
+		r := result{hit: h}
+		if len(h.node.words) > 0 {
+			combo, comboHit := tree.detectInCombo(runes, scanCombo(), h)
+			if !comboHit {
+				unresolved = append(unresolved, h)
+				continue
+			}
+			r.combo = combo
+		}
+		results = append(results, r)
+	}
+	if tree.hasRules() {
+		for _, layer := range layers {
+			for _, r := range layer.matchRules(scanCombo()) {
+				last := r.end
+				for _, h := range r.combo {
+					last = maxInt(last, h.end)
+				}
+				if tree.alive(r.node) && o.accept(r.node) && last >= from && last < to {
+					results = append(results, r)
+				}
+			}
+		}
+		results = sortResults(results)
+	}
+	return selectResults(results, o.policy), unresolved
+}
+
+// inRange 只保留结束位置在 [from, to) 内的命中
+func inRange(hits []hit, from, to int) []hit {
+	kept := hits[:0]
+	for _, h := range hits {
+		if h.end >= from && h.end < to {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// build 构建 Aho-Corasick 自动机的失败指针，每次新增敏感词后都需要重新构建
+func (tree *TrieTree) build() {
+	buildFailure(tree.root)
+	buildFailure(tree.comboRoot)
+	buildFailure(tree.allowRoot)
+	tree.maxDepth = treeDepth(tree.root)
+	tree.fuzzyRoot, tree.fuzzyDepth = nil, 0
+	if len(tree.fuzzyTiers) > 0 {
+		tree.fuzzyRoot = tree.buildFuzzy(tree.root)
+	}
+}
+
+func buildFailure(root *Node) {
+	queue := make([]*Node, 0, len(root.children))
+	for _, child := range root.children {
+		child.fail = root
+		child.output = nil
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for ch, child := range cur.children {
+			fail := cur.fail
+			for fail != nil {
+				if next, ok := fail.children[ch]; ok {
+					child.fail = next
+					break
+				}
+				fail = fail.fail
+			}
+			if fail == nil {
+				child.fail = root
+			}
+			if child.fail.isEnd {
+				child.output = child.fail
+			} else {
+				child.output = child.fail.output
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// next 状态转移，当前节点没有对应子节点时沿失败指针回退
+func (node *Node) next(ch rune) *Node {
+	cur := node
+	for {
+		if next, ok := cur.children[ch]; ok {
+			return next
+		}
+		if cur.isRoot {
+			return cur
+		}
+		cur = cur.fail
+	}
+}
+
+// cursor 自动机的扫描状态，流式查找时在块之间延续
+type cursor struct {
+	state *Node
+	// 非特殊字符在归一化文本中的下标，只需保留最近 state.depth 个
+	positions []int
+}
+
+// matches 单次线性扫描归一化后的文本，返回所有命中（含重叠），按起始位置、长度排序
+func (tree *TrieTree) matches(root *Node, runes []rune, units []unit) []hit {
+	c := &cursor{state: root, positions: make([]int, 0, len(units))}
+	return sortHits(tree.scan(c, runes, units, 0, len(units)))
+}
+
+// scan 从 c 的状态继续扫描 units[from:to]，返回以这些字符结尾的命中
+func (tree *TrieTree) scan(c *cursor, runes []rune, units []unit, from, to int) []hit {
+	var hits []hit
+	for position := from; position < to; position++ {
+		u := units[position]
+		if tree.isFilterChar(u.ch) {
+			continue
+		}
+		c.positions = append(c.positions, position)
+		c.state = c.state.next(u.ch)
+
+		out := c.state
+		if !out.isEnd {
+			out = out.output
+		}
+		for ; out != nil; out = out.output {
+			first := c.positions[len(c.positions)-out.depth]
+			start := units[first].start
+			if (out.wholeWord || tree.wholeWord) && !isWordBoundary(runes, start, u.end) {
+				continue
+			}
+			hits = append(hits, hit{
+				node:  out,
+				start: start,
+				end:   u.end,
+				first: first,
+				last:  position,
+			})
+		}
+	}
+	return hits
+}
+
+// sortHits 按起始位置、长度排序
+func sortHits(hits []hit) []hit {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].start != hits[j].start {
+			return hits[i].start < hits[j].start
+		}
+		return hits[i].end < hits[j].end
+	})
+	return hits
+}
+
+// sortResults 按起始位置、长度排序
+func sortResults(results []result) []result {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].start != results[j].start {
+			return results[i].start < results[j].start
+		}
+		return results[i].end < results[j].end
+	})
+	return results
+}
+
+// hitIndexes 命中范围内非特殊字符在原文中的下标
+func (tree *TrieTree) hitIndexes(units []unit, h hit) []int {
+	indexes := make([]int, 0, h.node.depth)
+	for _, u := range units[h.first : h.last+1] {
+		if tree.isFilterChar(u.ch) {
+			continue
+		}
+		for i := u.start; i <= u.end; i++ {
+			if len(indexes) > 0 && indexes[len(indexes)-1] >= i {
+				continue
+			}
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func hitWord(runes []rune, indexes []int) string {
+	word := make([]rune, 0, len(indexes))
+	for _, i := range indexes {
+		word = append(word, runes[i])
+	}
+	return string(word)
+}
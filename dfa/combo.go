@@ -0,0 +1,179 @@
+package dfa
+
+import "strings"
+
+const (
+	comboSeparator   = "|" // 组合词，各片段均出现即可
+	orderedSeparator = ">" // 有序组合词，各片段需按顺序出现
+)
+
+// ComboSeparator 组合词使用的分隔符，以 > 分隔出的片段均不为空时为有序组合词，如 司马南>美国 要求 美国 出现在 司马南 之后，
+// 存在空片段时 > 视为普通字符，如 <script> 仍按普通词处理
+func ComboSeparator(word string) string {
+	if !strings.Contains(word, orderedSeparator) {
+		return comboSeparator
+	}
+	for _, segment := range strings.Split(word, orderedSeparator) {
+		if segment == "" {
+			return comboSeparator
+		}
+	}
+	return orderedSeparator
+}
+
+// termKey 组合词片段、规则中的词归一化后的非特殊字符，与其在组合词字典树中的路径一致
+func (tree *TrieTree) termKey(word string) string {
+	var key []rune
+	for _, u := range tree.normalize([]rune(word)) {
+		if !tree.isFilterChar(u.ch) {
+			key = append(key, u.ch)
+		}
+	}
+	return string(key)
+}
+
+func (tree *TrieTree) termKeys(words []string) []string {
+	keys := make([]string, 0, len(words))
+	for _, word := range words {
+		keys = append(keys, tree.termKey(word))
+	}
+	return keys
+}
+
+// comboWord 结束节点对应的完整组合词
+func (node *Node) comboWord(word string) string {
+	if len(node.words) == 0 {
+		return word
+	}
+	separator := comboSeparator
+	if node.ordered {
+		separator = orderedSeparator
+	}
+	return word + separator + strings.Join(node.words, separator)
+}
+
+// sameCombo 结束节点是否对应同一个组合词
+func (node *Node) sameCombo(separator string, words []string) bool {
+	return equalWords(node.words, words) && (len(words) == 0 || node.ordered == (separator == orderedSeparator))
+}
+
+// proximity 组合词其他片段与首个片段之间的最大距离，为 0 表示不限制
+type proximity struct {
+	runes     int // 两个片段之间最多间隔的字符数
+	sentences int // 两个片段最多跨越的句子数，1 表示必须在同一句中
+}
+
+func (p proximity) bounded() bool {
+	return p.runes > 0 || p.sentences > 0
+}
+
+// WithComboWindow 限制组合词各片段之间的距离，runes 为最多间隔的字符数，sentences 为最多跨越的句子数，
+// 单个词可以通过 Entry.ComboRunes、Entry.ComboSentences 单独设置
+func (tree *TrieTree) WithComboWindow(runes, sentences int) *TrieTree {
+	tree.comboWindow = proximity{runes: runes, sentences: sentences}
+	return tree
+}
+
+// comboProximity 组合词生效的距离限制，词上的设置优先
+func (tree *TrieTree) comboProximity(node *Node) proximity {
+	if node.proximity.bounded() {
+		return node.proximity
+	}
+	return tree.comboWindow
+}
+
+// detectInCombo 检查组合词的其他片段是否全部命中。不限制距离时每个片段只取首次出现的位置，
+// 限制距离时返回窗口内的所有出现位置，窗口外的出现既不参与判断也不会被替换
+func (tree *TrieTree) detectInCombo(runes []rune, comboHits []hit, h hit) ([]hit, bool) {
+	window := tree.comboProximity(h.node)
+	if h.node.ordered {
+		return detectInOrder(runes, comboHits, h, window)
+	}
+
+	var (
+		wordMap = make(map[string]struct{}, len(h.node.words))
+		found   = make(map[string]struct{}, len(h.node.words))
+		hits    []hit
+	)
+	for _, key := range h.node.keys {
+		wordMap[key] = struct{}{}
+	}
+	for _, c := range comboHits {
+		if _, ok := wordMap[c.node.key]; !ok {
+			continue
+		}
+		if !window.bounded() {
+			if _, ok := found[c.node.key]; ok {
+				continue
+			}
+		} else if !window.contains(runes, h, c) {
+			continue
+		}
+		found[c.node.key] = struct{}{}
+		hits = append(hits, c)
+		if !window.bounded() && len(found) == len(wordMap) {
+			break
+		}
+	}
+	if len(found) < len(wordMap) {
+		return nil, false
+	}
+	return hits, true
+}
+
+// detectInOrder 有序组合词的各片段依次出现在前一个片段之后，每个片段取最早满足顺序的位置
+func detectInOrder(runes []rune, comboHits []hit, h hit, window proximity) ([]hit, bool) {
+	var (
+		hits []hit
+		last = h
+	)
+	for _, key := range h.node.keys {
+		found := false
+		for _, c := range comboHits {
+			if c.node.key != key || c.start <= last.end {
+				continue
+			}
+			if window.bounded() && !window.contains(runes, h, c) {
+				continue
+			}
+			hits = append(hits, c)
+			last, found = c, true
+			break
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return hits, true
+}
+
+// contains 片段 c 是否在首个片段 h 的窗口内，重叠或相邻的距离为 0
+func (p proximity) contains(runes []rune, h, c hit) bool {
+	from, to := h.end+1, c.start
+	if c.start < h.start {
+		from, to = c.end+1, h.start
+	}
+	if p.runes > 0 && to-from > p.runes {
+		return false
+	}
+	if p.sentences > 0 {
+		sentences := 1
+		for i := from; i < to; i++ {
+			if isSentenceEnd(runes[i]) {
+				sentences++
+			}
+		}
+		if sentences > p.sentences {
+			return false
+		}
+	}
+	return true
+}
+
+func isSentenceEnd(ch rune) bool {
+	switch ch {
+	case '。', '！', '？', '；', '!', '?', ';', '.', '\n':
+		return true
+	}
+	return false
+}
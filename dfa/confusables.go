@@ -0,0 +1,122 @@
+package dfa
+
+// DefaultConfusables 默认的形近字符映射表，包含 Unicode confusables 与 leetspeak 两部分，
+// 返回的是副本，可以按需增删后通过 WithConfusables 传入
+func DefaultConfusables() map[rune]rune {
+	table := make(map[rune]rune, len(unicodeConfusables)+len(leetConfusables))
+	for ch, to := range unicodeConfusables {
+		table[ch] = to
+	}
+	for ch, to := range leetConfusables {
+		table[ch] = to
+	}
+	return table
+}
+
+// unicodeConfusables 整理自 Unicode confusables.txt 中与拉丁字母外形相同的常用字符，
+// 全角字母、数学字母等兼容字符由 NFKC 归一化处理
+var unicodeConfusables = map[rune]rune{
+	// 西里尔字母
+	'\u0430': 'a', // CYRILLIC SMALL LETTER A
+	'\u0435': 'e', // CYRILLIC SMALL LETTER IE
+	'\u043E': 'o', // CYRILLIC SMALL LETTER O
+	'\u0440': 'p', // CYRILLIC SMALL LETTER ER
+	'\u0441': 'c', // CYRILLIC SMALL LETTER ES
+	'\u0443': 'y', // CYRILLIC SMALL LETTER U
+	'\u0445': 'x', // CYRILLIC SMALL LETTER HA
+	'\u0455': 's', // CYRILLIC SMALL LETTER DZE
+	'\u0456': 'i', // CYRILLIC SMALL LETTER BYELORUSSIAN-UKRAINIAN I
+	'\u0458': 'j', // CYRILLIC SMALL LETTER JE
+	'\u04BB': 'h', // CYRILLIC SMALL LETTER SHHA
+	'\u0501': 'd', // CYRILLIC SMALL LETTER KOMI DE
+	'\u051B': 'q', // CYRILLIC SMALL LETTER QA
+	'\u051D': 'w', // CYRILLIC SMALL LETTER WE
+	'\u04CF': 'l', // CYRILLIC SMALL LETTER PALOCHKA
+	'\u0475': 'v', // CYRILLIC SMALL LETTER IZHITSA
+	'\u04AF': 'y', // CYRILLIC SMALL LETTER STRAIGHT U
+	'\u0410': 'A', // CYRILLIC CAPITAL LETTER A
+	'\u0412': 'B', // CYRILLIC CAPITAL LETTER VE
+	'\u0415': 'E', // CYRILLIC CAPITAL LETTER IE
+	'\u041A': 'K', // CYRILLIC CAPITAL LETTER KA
+	'\u041C': 'M', // CYRILLIC CAPITAL LETTER EM
+	'\u041D': 'H', // CYRILLIC CAPITAL LETTER EN
+	'\u041E': 'O', // CYRILLIC CAPITAL LETTER O
+	'\u0420': 'P', // CYRILLIC CAPITAL LETTER ER
+	'\u0421': 'C', // CYRILLIC CAPITAL LETTER ES
+	'\u0422': 'T', // CYRILLIC CAPITAL LETTER TE
+	'\u0425': 'X', // CYRILLIC CAPITAL LETTER HA
+	'\u0405': 'S', // CYRILLIC CAPITAL LETTER DZE
+	'\u0406': 'I', // CYRILLIC CAPITAL LETTER BYELORUSSIAN-UKRAINIAN I
+	'\u0408': 'J', // CYRILLIC CAPITAL LETTER JE
+	'\u04AE': 'Y', // CYRILLIC CAPITAL LETTER STRAIGHT U
+	'\u051A': 'Q', // CYRILLIC CAPITAL LETTER QA
+	'\u051C': 'W', // CYRILLIC CAPITAL LETTER WE
+	'\u04C0': 'l', // CYRILLIC LETTER PALOCHKA
+	// 希腊字母
+	'\u0391': 'A', // GREEK CAPITAL LETTER ALPHA
+	'\u0392': 'B', // GREEK CAPITAL LETTER BETA
+	'\u0395': 'E', // GREEK CAPITAL LETTER EPSILON
+	'\u0396': 'Z', // GREEK CAPITAL LETTER ZETA
+	'\u0397': 'H', // GREEK CAPITAL LETTER ETA
+	'\u0399': 'I', // GREEK CAPITAL LETTER IOTA
+	'\u039A': 'K', // GREEK CAPITAL LETTER KAPPA
+	'\u039C': 'M', // GREEK CAPITAL LETTER MU
+	'\u039D': 'N', // GREEK CAPITAL LETTER NU
+	'\u039F': 'O', // GREEK CAPITAL LETTER OMICRON
+	'\u03A1': 'P', // GREEK CAPITAL LETTER RHO
+	'\u03A4': 'T', // GREEK CAPITAL LETTER TAU
+	'\u03A5': 'Y', // GREEK CAPITAL LETTER UPSILON
+	'\u03A7': 'X', // GREEK CAPITAL LETTER CHI
+	'\u03B1': 'a', // GREEK SMALL LETTER ALPHA
+	'\u03B9': 'i', // GREEK SMALL LETTER IOTA
+	'\u03BD': 'v', // GREEK SMALL LETTER NU
+	'\u03BF': 'o', // GREEK SMALL LETTER OMICRON
+	'\u03C1': 'p', // GREEK SMALL LETTER RHO
+	'\u03C5': 'u', // GREEK SMALL LETTER UPSILON
+	'\u03F2': 'c', // GREEK LUNATE SIGMA SYMBOL
+	'\u03F3': 'j', // GREEK LETTER YOT
+	'\u03B3': 'y', // GREEK SMALL LETTER GAMMA
+	// 拉丁字母扩展
+	'\u0131': 'i', // LATIN SMALL LETTER DOTLESS I
+	'\u0237': 'j', // LATIN SMALL LETTER DOTLESS J
+	'\u0251': 'a', // LATIN SMALL LETTER ALPHA
+	'\u0261': 'g', // LATIN SMALL LETTER SCRIPT G
+	'\u0269': 'i', // LATIN SMALL LETTER IOTA
+	'\u2113': 'l', // SCRIPT SMALL L
+	'\u01C0': 'l', // LATIN LETTER DENTAL CLICK
+	// 亚美尼亚字母
+	'\u0585': 'o', // ARMENIAN SMALL LETTER OH
+	'\u057D': 'u', // ARMENIAN SMALL LETTER SEH
+	'\u0570': 'h', // ARMENIAN SMALL LETTER HO
+	'\u0578': 'n', // ARMENIAN SMALL LETTER VO
+	'\u0581': 'g', // ARMENIAN SMALL LETTER CO
+	// 切罗基字母
+	'\u13AA': 'A', // CHEROKEE LETTER GO
+	'\u13F4': 'B', // CHEROKEE LETTER YV
+	'\u13DF': 'C', // CHEROKEE LETTER TLI
+	'\u13AC': 'E', // CHEROKEE LETTER GV
+	'\u13BB': 'H', // CHEROKEE LETTER MI
+	'\u13AB': 'J', // CHEROKEE LETTER GU
+	'\u13E6': 'K', // CHEROKEE LETTER TSO
+	'\u13B7': 'M', // CHEROKEE LETTER LU
+	'\u13E2': 'P', // CHEROKEE LETTER TLV
+	'\u13DA': 'S', // CHEROKEE LETTER DU
+	'\u13A2': 'T', // CHEROKEE LETTER I
+	'\u13D9': 'V', // CHEROKEE LETTER DO
+	'\u13B3': 'W', // CHEROKEE LETTER LA
+	'\u13C3': 'Z', // CHEROKEE LETTER NO
+}
+
+// leetConfusables 常见的 leetspeak 替换，如 sh1t、$hit
+var leetConfusables = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+}
@@ -0,0 +1,257 @@
+package dfa
+
+import "strings"
+
+// 增量词库累计的增删超过该数量时合并到主词库，合并需要重建整个词库，
+// 每次写时复制只需重建增量词库，耗时与增量词库的大小相关，与主词库的大小无关
+const mergeThreshold = 1024
+
+// change 一次写时复制的增删，合并到主词库之前保存在增量词库中
+type change struct {
+	entries []Entry
+	words   []string
+	allows  []string
+}
+
+func (c change) size() int {
+	return len(c.entries) + len(c.words) + len(c.allows)
+}
+
+// CloneAdd 写时复制：返回追加 entries 后的副本，原词库不受影响，耗时与主词库的大小无关
+func (tree *TrieTree) CloneAdd(entries ...Entry) *TrieTree {
+	return tree.cloneWith(change{entries: entries})
+}
+
+// CloneRemove 写时复制：返回删除 words 后的副本，原词库不受影响，耗时与主词库的大小无关
+func (tree *TrieTree) CloneRemove(words ...string) *TrieTree {
+	return tree.cloneWith(change{words: words})
+}
+
+// cloneWith 写时复制：副本与原词库共用只读的主词库，自上次合并以来的增删重新应用到新的增量词库，
+// 扫描时两者一起扫描；增删累计超过 mergeThreshold 时合并为新的主词库，之后的副本不再引用原来的主词库
+func (tree *TrieTree) cloneWith(c change) *TrieTree {
+	base, changes := tree, []change{c}
+	if tree.base != nil {
+		base = tree.base
+		changes = append(append(make([]change, 0, len(tree.changes)+1), tree.changes...), c)
+	}
+
+	size := 0
+	for _, c := range changes {
+		size += c.size()
+	}
+	var clone *TrieTree
+	if size > mergeThreshold {
+		clone = base.clone()
+	} else {
+		clone = base.config()
+		clone.base, clone.refs, clone.changes = base, map[*Node]int{}, changes
+	}
+	for _, c := range changes {
+		clone.apply(c)
+	}
+	clone.build()
+	if tree.base != nil {
+		clone.inheritStats(tree)
+	}
+	return clone
+}
+
+// record 记录增量词库中的增删，合并时重新应用
+func (tree *TrieTree) record(c change) {
+	if tree.base != nil {
+		tree.changes = append(tree.changes, c)
+	}
+}
+
+// apply 应用一次增删，不构建失败指针
+func (tree *TrieTree) apply(c change) {
+	for _, entry := range c.entries {
+		tree.addEntry(entry)
+	}
+	for _, word := range c.words {
+		tree.removeEntry(word)
+	}
+	for _, word := range c.allows {
+		if !tree.removeAllow(word) && tree.base != nil {
+			tree.release(tree.base.ends(Entry{Word: word, Allow: true}))
+		}
+	}
+}
+
+// addEntry 新增词，主词库中已有的词只增加引用计数
+func (tree *TrieTree) addEntry(entry Entry) {
+	if tree.base != nil {
+		if ends := tree.base.ends(entry); len(ends) > 0 {
+			for _, end := range ends {
+				tree.refs[end]++
+			}
+			return
+		}
+	}
+	tree.addWord(false, entry)
+}
+
+// removeEntry 删除词，增量词库中没有时减少主词库中的引用计数
+func (tree *TrieTree) removeEntry(word string) {
+	if tree.removeRegexp(word) || tree.removeRule(word) || tree.removeWord(false, word) {
+		return
+	}
+	if tree.base == nil {
+		return
+	}
+	for _, entry := range []Entry{{Word: word, Regexp: true}, {Word: word, Rule: true}, {Word: word}} {
+		if ends := tree.base.ends(entry); len(ends) > 0 {
+			tree.release(ends)
+			return
+		}
+	}
+}
+
+// release 减少主词库中结束节点的引用计数，已经删除的词不再减少
+func (tree *TrieTree) release(ends []*Node) {
+	if len(ends) == 0 || !tree.alive(ends[0]) {
+		return
+	}
+	for _, end := range ends {
+		tree.refs[end]--
+	}
+}
+
+// ends 返回词在词库中的结束节点，不存在时返回空
+func (tree *TrieTree) ends(entry Entry) []*Node {
+	switch {
+	case entry.Regexp:
+		if r, ok := tree.regexps[entry.Word]; ok {
+			return []*Node{r.node}
+		}
+		return nil
+	case entry.Rule:
+		if r, ok := tree.rules[entry.Word]; ok {
+			return []*Node{r.node}
+		}
+		return nil
+	case entry.Allow:
+		path := tree.findLiteral(tree.allowRoot, entry.Word)
+		if len(path) == 0 {
+			return nil
+		}
+		if cur := path[len(path)-1]; !cur.isRoot && cur.isEnd && cur.word == entry.Word {
+			return []*Node{cur}
+		}
+		return nil
+	}
+
+	separator := ComboSeparator(entry.Word)
+	words := strings.Split(entry.Word, separator)
+	if pattern, ok := tree.patterns[entry.Word]; ok {
+		steps, _ := tree.patternSteps(false, pattern, words[0])
+		ends := tree.patternRoot.findPattern(steps)
+		if len(ends) > 0 && ends[0].isEnd && ends[0].sameCombo(separator, words[1:]) {
+			return ends
+		}
+		return nil
+	}
+	path := tree.findLiteral(tree.root, words[0])
+	if len(path) == 0 {
+		return nil
+	}
+	if cur := path[len(path)-1]; !cur.isRoot && cur.isEnd && cur.sameCombo(separator, words[1:]) {
+		return []*Node{cur}
+	}
+	return nil
+}
+
+// alive 主词库中的节点在增删之后是否仍然有效，增量词库自身的节点总是有效
+func (tree *TrieTree) alive(node *Node) bool {
+	delta, ok := tree.refs[node]
+	return !ok || node.refs+delta > 0
+}
+
+// live 过滤主词库中已经删除的词的命中
+func (tree *TrieTree) live(hits []hit) []hit {
+	if len(tree.refs) == 0 {
+		return hits
+	}
+	kept := hits[:0]
+	for _, h := range hits {
+		if tree.alive(h.node) {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// layers 参与扫描的词库，增量词库在前，主词库在后
+func (tree *TrieTree) layers() []*TrieTree {
+	if tree.base == nil {
+		return []*TrieTree{tree}
+	}
+	return []*TrieTree{tree, tree.base}
+}
+
+// hasRules 是否存在布尔规则
+func (tree *TrieTree) hasRules() bool {
+	for _, layer := range tree.layers() {
+		if len(layer.rules) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueKeyHits 去掉位置相同、归一化结果相同的片段命中，增量词库与主词库可能包含同一个片段
+func uniqueKeyHits(hits []hit) []hit {
+	type key struct {
+		key        string
+		start, end int
+	}
+	var (
+		seen = make(map[key]struct{}, len(hits))
+		kept = hits[:0]
+	)
+	for _, h := range hits {
+		k := key{key: h.node.key, start: h.start, end: h.end}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		kept = append(kept, h)
+	}
+	return kept
+}
+
+// inheritStats 继承 old 增量词库中的命中统计，重新构建的增量词库或合并后的主词库不丢失合并前的统计
+func (tree *TrieTree) inheritStats(old *TrieTree) {
+	visited := map[*Node]struct{}{}
+	inheritNode(tree.root, old.root, visited)
+	inheritNode(tree.patternRoot, old.patternRoot, visited)
+	inheritNode(tree.allowRoot, old.allowRoot, visited)
+	for word, r := range old.regexps {
+		if cur, ok := tree.regexps[word]; ok {
+			inheritNode(cur.node, r.node, visited)
+		}
+	}
+	for word, r := range old.rules {
+		if cur, ok := tree.rules[word]; ok {
+			inheritNode(cur.node, r.node, visited)
+		}
+	}
+}
+
+// inheritNode 沿相同的路径拷贝结束节点的命中统计
+func inheritNode(node, old *Node, visited map[*Node]struct{}) {
+	if _, ok := visited[old]; ok {
+		return
+	}
+	visited[old] = struct{}{}
+	if old.isEnd && node.isEnd {
+		node.hitCount.Store(old.hitCount.Load())
+		node.suppressedCount.Store(old.suppressedCount.Load())
+	}
+	for ch, child := range old.children {
+		if cur, ok := node.children[ch]; ok {
+			inheritNode(cur, child, visited)
+		}
+	}
+}
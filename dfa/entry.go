@@ -0,0 +1,66 @@
+package dfa
+
+import (
+	"regexp"
+	"unicode"
+)
+
+// Entry 词典条目，用于给单个敏感词设置额外的匹配规则
+type Entry struct {
+	// 敏感词，组合词使用 | 分隔
+	Word string
+	// 要求首尾为单词边界，仅对拉丁、西里尔、希腊字母及数字生效，汉字仍按子串匹配
+	WholeWord bool
+	// 相邻字符之间允许出现的最多任意字符数，为 0 时使用分类或 TrieTree 的全局配置，小于 0 时不允许间隔
+	MaxGap int
+	// 分类，如 politics、abuse，命中时一并返回，可按分类筛选
+	Category string
+	// 严重程度，数值越大越严重，可按最低严重程度筛选
+	Severity int
+	// 替换文本，不为空时替换整个命中范围（包括其中的特殊字符），如 他妈的 替换为 哎呀
+	Replacement string
+	// 组合词其他片段与首个片段之间最多间隔的字符数，为 0 时使用 TrieTree 的全局配置
+	ComboRunes int
+	// 组合词其他片段与首个片段最多跨越的句子数，1 表示必须在同一句中
+	ComboSentences int
+	// 扩展出的变体对应的原词，如拼音变体 zhongkou 的原词为 重口，为空表示原词本身
+	Source string
+	// Word 为正则表达式，在原文上执行（开启 NFKC 时统一全角/半角），不区分大小写需使用 (?i)，不支持组合词
+	Regexp bool
+	// Word 为规则表达式，如 (枪支|弹药) AND 出售 AND NOT 新闻，替换时只替换正向的词
+	Rule bool
+	// 规则名称，命中时代替表达式返回，为空时使用表达式本身
+	Name string
+	// Word 为白名单短语，被短语完整覆盖的命中不再返回
+	Allow bool
+}
+
+// Validate 校验条目，检查正则表达式能否编译、规则表达式能否解析
+func (e Entry) Validate() error {
+	if e.Regexp {
+		if _, err := regexp.Compile(e.Word); err != nil {
+			return err
+		}
+	}
+	if e.Rule {
+		if _, err := parseRule(e.Word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isWordBoundary 命中的首尾字符若为单词字符，则其前后不能紧邻单词字符
+func isWordBoundary(runes []rune, start, end int) bool {
+	if isWordChar(runes[start]) && start > 0 && isWordChar(runes[start-1]) {
+		return false
+	}
+	if isWordChar(runes[end]) && end < len(runes)-1 && isWordChar(runes[end+1]) {
+		return false
+	}
+	return true
+}
+
+func isWordChar(ch rune) bool {
+	return unicode.IsDigit(ch) || unicode.In(ch, unicode.Latin, unicode.Cyrillic, unicode.Greek)
+}
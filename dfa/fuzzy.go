@@ -0,0 +1,331 @@
+package dfa
+
+import "sort"
+
+// FuzzyTier 模糊匹配的分档，不少于 MinLength 个字符的词允许最多 Distance 次编辑
+type FuzzyTier struct {
+	MinLength int
+	Distance  int
+}
+
+// fuzzyNode 只包含参与模糊匹配的词的字典树，由 build 从 root 中提取，避免遍历汉字等无关的子节点
+type fuzzyNode struct {
+	ch       rune
+	node     *Node        // 对应 root 中的节点
+	distance int          // 结束节点允许的编辑次数，为 0 时不报告
+	children []*fuzzyNode // 按字符排序
+	keys     []rune       // 子节点的字符，与 children 一一对应，查找时不必访问子节点
+}
+
+// fuzzyMatcher 沿 fuzzyNode 深度优先计算编辑距离，每层的距离行在所有起点之间复用
+type fuzzyMatcher struct {
+	// 从当前起点开始的非特殊字符
+	chars []rune
+	// 编辑距离超过 limit 时剪枝
+	limit int
+	// rows[d] 为深度 d 的编辑距离行，rows[d][j] 为路径与 chars[:j] 的距离，只计算 d-limit 至 d+limit 的部分
+	rows [][]int
+	// path[d] 为深度 d 的节点字符，用于判断相邻交换
+	path []rune
+	// children[d] 为深度 d 的节点中需要计算的子节点
+	children [][]*fuzzyNode
+	found    func(node *Node, length, distance int)
+}
+
+// isFuzzyWord 仅拉丁字母、数字组成的词参与模糊匹配，汉字过短且字形差异大，容易误报
+func isFuzzyWord(word string) bool {
+	for _, ch := range word {
+		if !isWordChar(ch) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// WithFuzzyTiers 按词长分档设置模糊匹配允许的编辑次数（增、删、改、相邻交换），仅对拉丁字母、数字词生效，
+// 如 {4, 1}、{8, 2} 表示 4 至 7 个字符的词允许 1 次、8 个及以上允许 2 次，短于所有分档的词不做模糊匹配
+func (tree *TrieTree) WithFuzzyTiers(tiers ...FuzzyTier) *TrieTree {
+	tiers = append([]FuzzyTier(nil), tiers...)
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return tiers[i].MinLength < tiers[j].MinLength
+	})
+	tree.fuzzyTiers = tiers
+	return tree
+}
+
+// fuzzyDistanceOf 词允许的最大编辑距离，短词不做模糊匹配
+func (tree *TrieTree) fuzzyDistanceOf(node *Node) int {
+	if !isFuzzyWord(node.word) {
+		return 0
+	}
+	distance := 0
+	for _, tier := range tree.fuzzyTiers {
+		if node.depth >= tier.MinLength {
+			distance = tier.Distance
+		}
+	}
+	return distance
+}
+
+// buildFuzzy 提取参与模糊匹配的词，没有这样的词时返回空
+func (tree *TrieTree) buildFuzzy(node *Node) *fuzzyNode {
+	f := &fuzzyNode{ch: node.character, node: node}
+	if node.isEnd {
+		f.distance = tree.fuzzyDistanceOf(node)
+	}
+	for ch, child := range node.children {
+		// 模糊匹配的词只包含单词字符
+		if !isWordChar(ch) {
+			continue
+		}
+		if c := tree.buildFuzzy(child); c != nil {
+			f.children = append(f.children, c)
+			tree.fuzzyDepth = maxInt(tree.fuzzyDepth, child.depth)
+		}
+	}
+	sort.Slice(f.children, func(i, j int) bool {
+		return f.children[i].ch < f.children[j].ch
+	})
+	for _, c := range f.children {
+		f.keys = append(f.keys, c.ch)
+	}
+	if f.distance <= 0 && len(f.children) == 0 && !node.isRoot {
+		return nil
+	}
+	return f
+}
+
+// child 查找字符对应的子节点，不存在时返回空
+func (f *fuzzyNode) child(ch rune) *fuzzyNode {
+	lo, hi := 0, len(f.keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch c := f.keys[mid]; {
+		case c == ch:
+			return f.children[mid]
+		case c < ch:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return nil
+}
+
+// matchFuzzy 从单词开头查找与词典词 Damerau-Levenshtein 距离（限制相邻交换）不超过阈值的片段，
+// 同一个词重叠的命中只保留距离最小的一个，已经精确命中的范围不再报告模糊命中
+func (tree *TrieTree) matchFuzzy(runes []rune, units []unit, exact []hit) []hit {
+	if tree.fuzzyRoot == nil || len(tree.fuzzyRoot.children) == 0 {
+		return nil
+	}
+
+	var (
+		positions  = make([]int, 0, len(units))
+		chars      = make([]rune, 0, len(units))
+		candidates = map[*Node][]hit{}
+	)
+	for position, u := range units {
+		if tree.isFilterChar(u.ch) {
+			continue
+		}
+		positions = append(positions, position)
+		chars = append(chars, u.ch)
+	}
+
+	m := &fuzzyMatcher{
+		rows:     make([][]int, tree.fuzzyDepth+1),
+		path:     make([]rune, tree.fuzzyDepth+1),
+		children: make([][]*fuzzyNode, tree.fuzzyDepth+1),
+	}
+	for _, tier := range tree.fuzzyTiers {
+		m.limit = maxInt(m.limit, tier.Distance)
+	}
+	for d := range m.rows {
+		m.rows[d] = make([]int, tree.fuzzyDepth+m.limit+2)
+	}
+	var i int
+	m.found = func(node *Node, length, distance int) {
+		first, last := positions[i], positions[i+length-1]
+		if (node.wholeWord || tree.wholeWord) && !isWordBoundary(runes, units[first].start, units[last].end) {
+			return
+		}
+		candidates[node] = append(candidates[node], hit{
+			node:     node,
+			start:    units[first].start,
+			end:      units[last].end,
+			first:    first,
+			last:     last,
+			distance: distance,
+		})
+	}
+	for i = range chars {
+		// 只从单词开头查找，单词中间开始的片段与词典词相似通常只是巧合
+		if p := positions[i]; !isWordChar(chars[i]) || (p > 0 && isWordChar(units[p-1].ch)) {
+			continue
+		}
+		m.chars = chars[i:minInt(len(chars), i+tree.fuzzyDepth+m.limit)]
+		row := m.rows[0]
+		for j := 0; j <= minInt(len(m.chars), m.limit); j++ {
+			row[j] = j
+		}
+		if m.limit+1 <= len(m.chars) {
+			row[m.limit+1] = m.limit + 1
+		}
+		m.walk(tree.fuzzyRoot, 0, 0)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	for _, h := range exact {
+		if _, ok := candidates[h.node]; ok {
+			candidates[h.node] = append(candidates[h.node], h)
+		}
+	}
+
+	var hits []hit
+	for _, nodeHits := range candidates {
+		sort.SliceStable(nodeHits, func(i, j int) bool {
+			if nodeHits[i].distance != nodeHits[j].distance {
+				return nodeHits[i].distance < nodeHits[j].distance
+			}
+			if nodeHits[i].start != nodeHits[j].start {
+				return nodeHits[i].start < nodeHits[j].start
+			}
+			return nodeHits[i].end < nodeHits[j].end
+		})
+		var selected []hit
+		for _, h := range nodeHits {
+			overlap := false
+			for _, s := range selected {
+				if h.start <= s.end && s.start <= h.end {
+					overlap = true
+					break
+				}
+			}
+			if overlap {
+				continue
+			}
+			selected = append(selected, h)
+			if h.distance > 0 {
+				hits = append(hits, h)
+			}
+		}
+	}
+	return hits
+}
+
+// walk 逐层计算 node 子节点的编辑距离行，行内最小值超过阈值时剪枝，distance 为 node 所在行的最小值。
+// 距离超过 limit 的格子不影响结果，每行只计算对角线附近 2*limit+1 个格子，两侧各留一个哨兵
+func (m *fuzzyMatcher) walk(node *fuzzyNode, depth, distance int) {
+	if len(node.children) == 0 {
+		return
+	}
+	d := depth + 1
+	var (
+		size  = len(m.chars)
+		prev  = m.rows[depth]
+		row   = m.rows[d]
+		lo    = maxInt(1, d-m.limit)
+		hi    = minInt(size, d+m.limit)
+		grand []int
+	)
+	if depth > 0 {
+		grand = m.rows[depth-1]
+	}
+	children := node.children
+	if distance >= m.limit {
+		// 距离已达上限，字符不在对角线附近的子节点只会继续增加距离
+		children = m.children[depth][:0]
+		for j := maxInt(0, lo-2); j < hi; j++ {
+			if child := node.child(m.chars[j]); child != nil && !containsFuzzyNode(children, child) {
+				children = append(children, child)
+			}
+		}
+		m.children[depth] = children
+	}
+	for _, child := range children {
+		ch := child.ch
+		row[0] = d
+		if lo > 1 {
+			row[lo-1] = m.limit + 1
+		}
+		if hi+1 <= size {
+			row[hi+1] = m.limit + 1
+		}
+		minDistance := row[0]
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if m.chars[j-1] == ch {
+				cost = 0
+			}
+			row[j] = minInt(minInt(prev[j]+1, row[j-1]+1), prev[j-1]+cost)
+			if grand != nil && j > 1 && m.chars[j-2] == ch && m.chars[j-1] == m.path[depth] {
+				row[j] = minInt(row[j], grand[j-2]+1)
+			}
+			minDistance = minInt(minDistance, row[j])
+		}
+		if minDistance > m.limit {
+			continue
+		}
+
+		if k := child.distance; k > 0 {
+			// 距离相同时取长度与词最接近的片段
+			length := 0
+			for j := lo; j <= hi; j++ {
+				if row[j] > k {
+					continue
+				}
+				if length == 0 || row[j] < row[length] ||
+					(row[j] == row[length] && absInt(j-d) < absInt(length-d)) {
+					length = j
+				}
+			}
+			if length > 0 {
+				m.found(child.node, length, row[length])
+			}
+		}
+
+		m.path[d] = ch
+		m.walk(child, d, minDistance)
+	}
+}
+
+func containsFuzzyNode(nodes []*fuzzyNode, node *fuzzyNode) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// treeDepth 字典树的最大深度，用于限制模糊匹配的窗口长度
+func treeDepth(node *Node) int {
+	depth := node.depth
+	for _, child := range node.children {
+		depth = maxInt(depth, treeDepth(child))
+	}
+	return depth
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
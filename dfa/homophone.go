@@ -0,0 +1,69 @@
+package dfa
+
+import "unicode"
+
+// 读音对应的私有区字符，同音字折叠为同一个字符，补充私有区 A 不会出现在正常文本中
+const (
+	syllableBase rune = 0xF0000
+	syllableMax  rune = 0xFFFFD
+)
+
+// WithHomophone 开启同音字匹配，汉字按读音折叠后再匹配，如 瞅八怪、丑巴怪 可以命中 丑八怪。
+// convert 返回的第一个读音作为折叠依据，是否区分声调由 convert 决定
+func (tree *TrieTree) WithHomophone(convert PinyinFunc) *TrieTree {
+	tree.homophone = convert
+	tree.syllableRunes = map[string]rune{}
+	return tree
+}
+
+// addSyllables 为词中汉字的读音分配字符，只在新增敏感词与白名单短语时分配，扫描时读音不在表中说明不会命中任何词
+func (tree *TrieTree) addSyllables(word string) {
+	if tree.homophone == nil {
+		return
+	}
+	for _, u := range tree.normalize([]rune(word)) {
+		if !unicode.Is(unicode.Han, u.ch) {
+			continue
+		}
+		readings := tree.homophone(u.ch)
+		if len(readings) == 0 {
+			continue
+		}
+		if _, ok := tree.syllableRunes[readings[0]]; ok {
+			continue
+		}
+		ch := syllableBase + rune(len(tree.syllables))
+		if ch > syllableMax {
+			return
+		}
+		tree.syllableRunes[readings[0]] = ch
+		tree.syllables = append(tree.syllables, readings[0])
+	}
+}
+
+// homophones 将汉字替换为其读音对应的字符
+func (tree *TrieTree) homophones(units []unit) []unit {
+	for i, u := range units {
+		if !unicode.Is(unicode.Han, u.ch) {
+			continue
+		}
+		if readings := tree.homophone(u.ch); len(readings) > 0 {
+			if ch, ok := tree.syllableRunes[readings[0]]; ok {
+				units[i].ch = ch
+			}
+		}
+	}
+	return units
+}
+
+// syllable 读音字符对应的读音
+func (tree *TrieTree) syllable(ch rune) (string, bool) {
+	if ch < syllableBase || ch >= syllableBase+rune(len(tree.syllables)) {
+		return "", false
+	}
+	return tree.syllables[ch-syllableBase], true
+}
+
+func isSyllableRune(ch rune) bool {
+	return ch >= syllableBase && ch <= syllableMax
+}
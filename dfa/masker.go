@@ -0,0 +1,143 @@
+package dfa
+
+import (
+	"sort"
+	"strings"
+)
+
+// Masker 替换策略，Replace 对每个命中调用一次，相互重叠的命中合并为一个命中。chars 为命中中的非特殊字符，
+// 返回值与 chars 一一对应，为每个字符的替换文本，特殊字符不经过 Masker，始终保持不变
+type Masker interface {
+	Mask(match *Match, chars []rune) []string
+}
+
+// MaskerFunc 自定义替换，返回的文本放在首个字符的位置，其余字符删除，
+// 命中中的特殊字符仍保留在原位，因此返回的文本不宜包含 match.Text 中的特殊字符
+type MaskerFunc func(match *Match) string
+
+func (fn MaskerFunc) Mask(match *Match, chars []rune) []string {
+	return placeFirst(fn(match), len(chars))
+}
+
+// RuneMasker 逐字替换，如 丑八怪 替换为 ***，默认策略
+func RuneMasker(mask rune) Masker {
+	return runeMasker(mask)
+}
+
+type runeMasker rune
+
+func (m runeMasker) Mask(match *Match, chars []rune) []string {
+	masks := make([]string, len(chars))
+	for i := range masks {
+		masks[i] = string(rune(m))
+	}
+	return masks
+}
+
+// SingleMasker 整个命中只替换为一个 mask，如 丑八怪 替换为 *
+func SingleMasker(mask rune) Masker {
+	return singleMasker(mask)
+}
+
+type singleMasker rune
+
+func (m singleMasker) Mask(match *Match, chars []rune) []string {
+	return placeFirst(string(rune(m)), len(chars))
+}
+
+// KeepEndsMasker 保留首尾字符，中间逐字替换，如 丑八怪 替换为 丑*怪，不超过两个字符时全部替换
+func KeepEndsMasker(mask rune) Masker {
+	return keepEndsMasker(mask)
+}
+
+type keepEndsMasker rune
+
+func (m keepEndsMasker) Mask(match *Match, chars []rune) []string {
+	masks := runeMasker(m).Mask(match, chars)
+	if len(chars) > 2 {
+		masks[0] = string(chars[0])
+		masks[len(masks)-1] = string(chars[len(chars)-1])
+	}
+	return masks
+}
+
+// DeleteMasker 删除命中的字符
+func DeleteMasker() Masker {
+	return MaskerFunc(func(match *Match) string {
+		return ""
+	})
+}
+
+// FixedMasker 替换为固定长度的 mask，隐藏命中的长度，如 丑八怪、笨蛋 均替换为 ***
+func FixedMasker(mask rune, width int) Masker {
+	fixed := strings.Repeat(string(mask), width)
+	return MaskerFunc(func(match *Match) string {
+		return fixed
+	})
+}
+
+// mask 相互重叠的命中合并为一个范围后调用一次 Masker，避免整体替换类的策略在重叠的命中上重复生效，
+// 合并后的 Match 沿用其中最长的命中的词，特殊字符不替换
+func (tree *TrieTree) mask(text string, offsets []int, runes []rune, units []unit, hits []hit, masker Masker) []span {
+	var (
+		spans []span
+		group []hit
+		end   = -1
+	)
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		var (
+			main    = group[0]
+			seen    = map[int]struct{}{}
+			indexes []int
+		)
+		for _, h := range group {
+			if h.end-h.start > main.end-main.start {
+				main = h
+			}
+			for _, i := range tree.hitIndexes(units, h) {
+				if _, ok := seen[i]; !ok {
+					seen[i] = struct{}{}
+					indexes = append(indexes, i)
+				}
+			}
+		}
+		sort.Ints(indexes)
+		chars := make([]rune, len(indexes))
+		for j, i := range indexes {
+			chars[j] = runes[i]
+		}
+
+		merged := main
+		merged.start, merged.end = group[0].start, end
+		match := newMatch(text, offsets, merged)
+		match.Word = main.node.comboWord(match.Word)
+		for j, mask := range masker.Mask(match, chars) {
+			if j < len(indexes) {
+				spans = append(spans, span{start: indexes[j], end: indexes[j], text: mask})
+			}
+		}
+		group = group[:0]
+	}
+
+	for _, h := range sortHits(hits) {
+		if h.start > end {
+			flush()
+		}
+		group = append(group, h)
+		end = maxInt(end, h.end)
+	}
+	flush()
+	return spans
+}
+
+// placeFirst 替换文本放在首个字符的位置，其余字符删除
+func placeFirst(text string, size int) []string {
+	masks := make([]string, size)
+	if size > 0 {
+		masks[0] = text
+	}
+	return masks
+}
@@ -0,0 +1,72 @@
+package dfa
+
+import "unicode/utf8"
+
+// Match 命中位置，偏移量均为左闭右开区间
+type Match struct {
+	// 命中的词典词，组合词为完整的组合词，规则为规则名称
+	Word string
+	// 原文中的命中片段，包含被跳过的特殊字符
+	Text string
+	// 字节偏移
+	Start int
+	End   int
+	// 字符偏移
+	RuneStart int
+	RuneEnd   int
+	// 命中词的分类与严重程度
+	Category string
+	Severity int
+	// 模糊匹配的编辑距离，精确命中为 0，可据此降低命中的可信度
+	Distance int
+	// 组合词其他片段、规则其他正向词的命中位置
+	Combo []*Match
+	// 抑制该命中的白名单短语，仅出现在 WithSuppressed 收集的结果中
+	Allow string
+}
+
+// FindAll 查找所有命中，每次命中返回一个结果
+func (tree *TrieTree) FindAll(text string, opts ...ScanOption) []*Match {
+	var (
+		runes   = []rune(text)
+		offsets = byteOffsets(text)
+		matches []*Match
+	)
+
+	results, _ := tree.results(runes, tree.scanOptions(opts))
+	for _, r := range results {
+		r.node.incrStats(tree.openStats)
+		match := newMatch(text, offsets, r.hit)
+		match.Word = r.node.comboWord(match.Word)
+		// 组合词的其他片段、规则的其他正向词
+		for _, h := range r.combo {
+			match.Combo = append(match.Combo, newMatch(text, offsets, h))
+		}
+		matches = append(matches, match)
+	}
+
+	return matches
+}
+
+func newMatch(text string, offsets []int, h hit) *Match {
+	return &Match{
+		Word:      h.node.word,
+		Text:      text[offsets[h.start]:offsets[h.end+1]],
+		Start:     offsets[h.start],
+		End:       offsets[h.end+1],
+		RuneStart: h.start,
+		RuneEnd:   h.end + 1,
+		Category:  h.node.category,
+		Severity:  h.node.severity,
+		Distance:  h.distance,
+	}
+}
+
+// byteOffsets 每个字符的起始字节偏移，末尾追加总长度
+func byteOffsets(text string) []int {
+	offsets := make([]int, 0, utf8.RuneCountInString(text)+1)
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	return append(offsets, len(text))
+}
@@ -0,0 +1,121 @@
+package dfa
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// unit 归一化后的字符，start/end 为其对应的原文字符下标（闭区间）。
+// 一个原文字符可能对应多个归一化字符，多个原文字符也可能合并为一个归一化字符
+type unit struct {
+	ch    rune
+	start int
+	end   int
+}
+
+// normalize 归一化文本，词典与待检测文本使用同一套规则，保证两者可以直接比较
+func (tree *TrieTree) normalize(runes []rune) []unit {
+	units := make([]unit, len(runes))
+	for i, ch := range runes {
+		units[i] = unit{ch: ch, start: i, end: i}
+	}
+	if tree.nfkc {
+		units = nfkc(units)
+	}
+	if tree.traditional {
+		units = traditional(units)
+	}
+	if tree.homophone != nil {
+		units = tree.homophones(units)
+	}
+	if tree.confusables != nil {
+		units = confusables(units, tree.confusables)
+	}
+	if tree.caseFold {
+		units = caseFold(units)
+	}
+	return units
+}
+
+// nfkc 统一全角/半角后做 NFKC 兼容性归一化，如 ｆ、①、㎏ 归一化为 f、1、kg
+func nfkc(units []unit) []unit {
+	var (
+		normalized = make([]unit, 0, len(units))
+		segment    []rune
+		buf        [utf8.UTFMax]byte
+	)
+	// 组合字符序列整体归一化，结果对应原文中的多个字符
+	flush := func(first, last int) {
+		if len(segment) == 0 {
+			return
+		}
+		start, end := units[first].start, units[last].end
+		if len(segment) == 1 && segment[0] < utf8.RuneSelf {
+			normalized = append(normalized, unit{ch: segment[0], start: start, end: end})
+		} else {
+			for _, ch := range norm.NFKC.String(string(segment)) {
+				normalized = append(normalized, unit{ch: ch, start: start, end: end})
+			}
+		}
+		segment = segment[:0]
+	}
+
+	first := 0
+	for i, u := range units {
+		ch := u.ch
+		if folded := width.LookupRune(ch).Folded(); folded != 0 {
+			ch = folded
+		}
+		n := utf8.EncodeRune(buf[:], ch)
+		if norm.NFKC.Properties(buf[:n]).BoundaryBefore() {
+			flush(first, i-1)
+			first = i
+		}
+		segment = append(segment, ch)
+	}
+	flush(first, len(units)-1)
+
+	return normalized
+}
+
+// traditional 繁体字统一折叠为简体字，如 醜八怪 折叠为 丑八怪
+func traditional(units []unit) []unit {
+	for i, u := range units {
+		if ch, ok := traditionalTable[u.ch]; ok {
+			units[i].ch = ch
+		}
+	}
+	return units
+}
+
+// confusables 形近字符折叠，如 ѕh1t、$hit 折叠为 shit，需在特殊字符判断之前执行
+func confusables(units []unit, table map[rune]rune) []unit {
+	for i, u := range units {
+		if ch, ok := table[u.ch]; ok {
+			units[i].ch = ch
+		}
+	}
+	return units
+}
+
+// caseFold Unicode 完整大小写折叠，如 ß 折叠为 ss
+func caseFold(units []unit) []unit {
+	var (
+		caser  = cases.Fold()
+		folded = make([]unit, 0, len(units))
+	)
+	for _, u := range units {
+		if u.ch < utf8.RuneSelf || unicode.Is(unicode.Han, u.ch) {
+			folded = append(folded, unit{ch: unicode.ToLower(u.ch), start: u.start, end: u.end})
+			continue
+		}
+		for _, ch := range caser.String(string(u.ch)) {
+			folded = append(folded, unit{ch: ch, start: u.start, end: u.end})
+		}
+	}
+	return folded
+}
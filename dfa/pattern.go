@@ -0,0 +1,336 @@
+package dfa
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 模式节点中的特殊边，文本中的字符不会是负数，因此不会与普通字符冲突
+const anyRune rune = -1 // 匹配任意单个字符
+
+// patternStep 模式中的一步，任一候选序列命中即可，gap 为该步之前允许跳过的最多字符数
+type patternStep struct {
+	alternatives [][]rune
+	gap          int
+}
+
+// gapRune 跳跃节点对应的边，不同的跳跃长度对应不同的节点
+func gapRune(gap int) rune {
+	return anyRune - rune(gap)
+}
+
+// patternSteps 判断是否需要按模式匹配，并将词转换为模式
+func (tree *TrieTree) patternSteps(isCombo bool, entry Entry, word string) ([]patternStep, bool) {
+	if isCombo {
+		return nil, false
+	}
+
+	gap := entry.MaxGap
+	if categoryGap, ok := tree.categoryGaps[entry.Category]; ok && gap == 0 && entry.Category != "" {
+		gap = categoryGap
+	}
+	if gap == 0 && tree.maxGap > 0 && utf8.RuneCountInString(word) >= tree.maxGapMinLength {
+		gap = tree.maxGap
+	}
+	wildcard := tree.wildcard && strings.ContainsAny(word, "?*[")
+	mixed := tree.pinyin != nil && hasHan(word)
+	if gap <= 0 && !wildcard && !mixed {
+		return nil, false
+	}
+
+	var steps []patternStep
+	if wildcard {
+		steps = tree.parseWildcard(word)
+	} else {
+		for _, u := range tree.normalize([]rune(word)) {
+			if tree.isFilterChar(u.ch) {
+				continue
+			}
+			steps = append(steps, patternStep{alternatives: [][]rune{{u.ch}}})
+		}
+	}
+	for i := 1; i < len(steps); i++ {
+		if steps[i].gap < gap {
+			steps[i].gap = gap
+		}
+	}
+	if mixed {
+		steps = tree.pinyinSteps(steps)
+	}
+	return steps, len(steps) > 0
+}
+
+// addPattern 将模式插入模式节点，返回模式的结束节点。
+// 同一步的多个候选尽量汇聚到同一个新节点，避免按候选组合展开；
+// 汇聚节点只属于汇聚时的那一步，其他词经由部分候选到达时先拆分，如 丑、臭 同音时 丑八 与 臭虫 不能共用 chou 之后的节点
+func (node *Node) addPattern(steps []patternStep) []*Node {
+	frontier := []*Node{node}
+	for _, step := range steps {
+		if step.gap > 0 {
+			for i, cur := range frontier {
+				frontier[i] = cur.gapChild(step.gap)
+			}
+		}
+
+		var next []*Node
+		for _, cur := range frontier {
+			// 之前新增过同样的一步，所有候选汇聚到同一个节点
+			if end := cur.convergedChild(step.alternatives); end != nil {
+				next = appendNode(next, end)
+				continue
+			}
+
+			// 本步新建的节点，同一步的其他候选可以复用
+			var created *Node
+			for _, alternative := range step.alternatives {
+				parent := cur
+				for i, ch := range alternative {
+					last := i == len(alternative)-1
+					child, ok := parent.children[ch]
+					switch {
+					case !ok && last && created != nil:
+						child = created
+						child.parents++
+					case !ok:
+						child = NewNode(ch)
+						child.depth = parent.depth + 1
+						child.parents = 1
+						if last {
+							created = child
+						}
+					case child.parents > 1 && child != created:
+						child = child.split()
+					}
+					parent.children[ch] = child
+					parent = child
+				}
+				next = appendNode(next, parent)
+			}
+		}
+		frontier = next
+	}
+	return frontier
+}
+
+// convergedChild 所有候选都到达同一个已有节点，且该节点只由这些候选汇聚而来时返回该节点
+func (node *Node) convergedChild(alternatives [][]rune) *Node {
+	var (
+		end   *Node
+		edges = map[string]struct{}{}
+	)
+	for _, alternative := range alternatives {
+		parent := node
+		for i, ch := range alternative {
+			child, ok := parent.children[ch]
+			if !ok || (i < len(alternative)-1 && child.parents > 1) {
+				return nil
+			}
+			parent = child
+		}
+		if end != nil && parent != end {
+			return nil
+		}
+		end = parent
+		edges[string(alternative)] = struct{}{}
+	}
+	if end == nil || end.parents != len(edges) {
+		return nil
+	}
+	return end
+}
+
+// split 拷贝汇聚节点及其子节点，拷贝只属于当前路径，命中统计从零开始
+func (node *Node) split() *Node {
+	memo := map[*Node]*Node{}
+	clone := node.clone(memo)
+	for _, n := range memo {
+		n.hitCount.Store(0)
+		n.suppressedCount.Store(0)
+	}
+	node.parents--
+	clone.parents = 1
+	return clone
+}
+
+// findPattern 查找模式的结束节点，不存在时返回空
+func (node *Node) findPattern(steps []patternStep) []*Node {
+	frontier := []*Node{node}
+	for _, step := range steps {
+		if step.gap > 0 {
+			var gaps []*Node
+			for _, cur := range frontier {
+				if child, ok := cur.children[gapRune(step.gap)]; ok {
+					gaps = append(gaps, child)
+				}
+			}
+			frontier = gaps
+		}
+
+		var next []*Node
+		for _, cur := range frontier {
+			for _, alternative := range step.alternatives {
+				parent, ok := cur, true
+				for _, ch := range alternative {
+					if parent, ok = parent.children[ch]; !ok {
+						break
+					}
+				}
+				if ok {
+					next = appendNode(next, parent)
+				}
+			}
+		}
+		frontier = next
+	}
+	return frontier
+}
+
+// prunePattern 自底向上删除模式路径上不再通往任何结束节点的节点。
+// 路径上的边按深度依次记录，逆序处理时子节点总是先于父节点，汇聚节点的每条入边都在路径上，可以一并删除
+func (node *Node) prunePattern(steps []patternStep) {
+	type edge struct {
+		parent *Node
+		child  *Node
+		ch     rune
+	}
+
+	var (
+		edges    []edge
+		frontier = []*Node{node}
+	)
+	for _, step := range steps {
+		if step.gap > 0 {
+			var gaps []*Node
+			for _, cur := range frontier {
+				if child, ok := cur.children[gapRune(step.gap)]; ok {
+					edges = append(edges, edge{parent: cur, child: child, ch: gapRune(step.gap)})
+					gaps = append(gaps, child)
+				}
+			}
+			frontier = gaps
+		}
+
+		var next []*Node
+		for _, cur := range frontier {
+			for _, alternative := range step.alternatives {
+				parent, ok := cur, true
+				for _, ch := range alternative {
+					var child *Node
+					if child, ok = parent.children[ch]; !ok {
+						break
+					}
+					edges = append(edges, edge{parent: parent, child: child, ch: ch})
+					parent = child
+				}
+				if ok {
+					next = appendNode(next, parent)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	for i := len(edges) - 1; i >= 0; i-- {
+		e := edges[i]
+		if e.child.isEnd || len(e.child.children) > 0 || e.parent.children[e.ch] != e.child {
+			continue
+		}
+		delete(e.parent.children, e.ch)
+		for j, gap := range e.parent.gaps {
+			if gap == e.child {
+				e.parent.gaps = append(e.parent.gaps[:j], e.parent.gaps[j+1:]...)
+				break
+			}
+		}
+		e.child.parents--
+	}
+}
+
+func (node *Node) gapChild(gap int) *Node {
+	if child, ok := node.children[gapRune(gap)]; ok {
+		return child
+	}
+	child := NewNode(gapRune(gap))
+	child.depth = node.depth
+	child.gap = gap
+	child.parents = 1
+	node.children[gapRune(gap)] = child
+	node.gaps = append(node.gaps, child)
+	return child
+}
+
+func appendNode(nodes []*Node, node *Node) []*Node {
+	for _, n := range nodes {
+		if n == node {
+			return nodes
+		}
+	}
+	return append(nodes, node)
+}
+
+// patternThread 模式匹配中的一条候选路径
+type patternThread struct {
+	node    *Node
+	first   int // 起始字符在归一化文本中的下标
+	skipped int // 在跳跃节点上已跳过的字符数
+}
+
+// matchPatterns 逐字符模拟模式匹配，同时存活的路径数受模式长度与跳跃长度限制，扫描时间与文本长度线性相关
+func (tree *TrieTree) matchPatterns(runes []rune, units []unit) []hit {
+	if len(tree.patternRoot.children) == 0 {
+		return nil
+	}
+
+	var (
+		threads []patternThread
+		next    []patternThread
+		seen    = map[patternThread]struct{}{}
+		hits    []hit
+	)
+	for position, u := range units {
+		if tree.isFilterChar(u.ch) {
+			continue
+		}
+
+		next = next[:0]
+		for t := range seen {
+			delete(seen, t)
+		}
+		add := func(t patternThread) bool {
+			if _, ok := seen[t]; ok {
+				return false
+			}
+			seen[t] = struct{}{}
+			next = append(next, t)
+			return true
+		}
+		// 消耗当前字符后到达 node，同时进入其跳跃节点
+		advance := func(node *Node, first int) {
+			if add(patternThread{node: node, first: first}) && node.isEnd {
+				start := units[first].start
+				if !(node.wholeWord || tree.wholeWord) || isWordBoundary(runes, start, u.end) {
+					hits = append(hits, hit{node: node, start: start, end: u.end, first: first, last: position})
+				}
+			}
+			for _, gap := range node.gaps {
+				add(patternThread{node: gap, first: first})
+			}
+		}
+
+		threads = append(threads, patternThread{node: tree.patternRoot, first: position})
+		for _, t := range threads {
+			if child, ok := t.node.children[u.ch]; ok {
+				advance(child, t.first)
+			}
+			if child, ok := t.node.children[anyRune]; ok {
+				advance(child, t.first)
+			}
+			if t.skipped < t.node.gap {
+				add(patternThread{node: t.node, first: t.first, skipped: t.skipped + 1})
+			}
+		}
+		threads, next = next, threads
+	}
+
+	return hits
+}
@@ -0,0 +1,54 @@
+package dfa
+
+import "unicode"
+
+// PinyinFunc 返回汉字的拼音，多音字可返回多个读音，dfa 包本身不依赖拼音库
+type PinyinFunc func(ch rune) []string
+
+// WithPinyin 开启汉字与拼音混合匹配，词中的每个汉字既可以匹配汉字本身，也可以匹配其拼音，
+// 如 丑八怪 可以命中 丑ba怪、chou八guai 与 choubaguai
+func (tree *TrieTree) WithPinyin(convert PinyinFunc) *TrieTree {
+	tree.pinyin = convert
+	return tree
+}
+
+// hasHan 是否包含汉字，包含汉字的词在混合匹配时需要按模式匹配
+func hasHan(word string) bool {
+	for _, ch := range word {
+		if unicode.Is(unicode.Han, ch) {
+			return true
+		}
+	}
+	return false
+}
+
+// pinyinSteps 为每个汉字候选追加拼音候选，拼音作为模式节点中的另一条边，与汉字边汇聚到同一个节点
+func (tree *TrieTree) pinyinSteps(steps []patternStep) []patternStep {
+	for i, step := range steps {
+		var alternatives [][]rune
+		for _, alternative := range step.alternatives {
+			alternatives = append(alternatives, alternative)
+			if len(alternative) != 1 {
+				continue
+			}
+			var syllables []string
+			if syllable, ok := tree.syllable(alternative[0]); ok {
+				// 同音字折叠后只保留了读音
+				syllables = []string{syllable}
+			} else if unicode.Is(unicode.Han, alternative[0]) {
+				syllables = tree.pinyin(alternative[0])
+			}
+			for _, syllable := range syllables {
+				var chars []rune
+				for _, ch := range syllable {
+					chars = append(chars, tree.normalizeChar(ch)...)
+				}
+				if len(chars) > 0 {
+					alternatives = append(alternatives, chars)
+				}
+			}
+		}
+		steps[i].alternatives = alternatives
+	}
+	return steps
+}
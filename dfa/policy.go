@@ -0,0 +1,107 @@
+package dfa
+
+import "sort"
+
+// MatchPolicy 匹配策略
+type MatchPolicy int
+
+const (
+	MatchAll              MatchPolicy = iota // 返回所有命中，包括重叠的命中
+	MatchLeftmostLongest                     // 从左到右，同一起点取最长的命中，命中之间不重叠
+	MatchLeftmostShortest                    // 从左到右，同一起点取最短的命中，命中之间不重叠
+)
+
+// ScanOption 单次查找/替换的参数，覆盖 TrieTree 的默认配置
+type ScanOption func(*scanOptions)
+
+type scanOptions struct {
+	policy MatchPolicy
+	// 只返回这些分类的命中，为空表示不限制
+	categories []string
+	// 只返回严重程度不低于 minSeverity 的命中
+	minSeverity int
+	// 收集被白名单短语抑制的命中
+	suppressed *[]*Match
+}
+
+func WithPolicy(policy MatchPolicy) ScanOption {
+	return func(o *scanOptions) {
+		o.policy = policy
+	}
+}
+
+// WithCategories 只返回指定分类的命中
+func WithCategories(categories ...string) ScanOption {
+	return func(o *scanOptions) {
+		o.categories = categories
+	}
+}
+
+// WithMinSeverity 只返回严重程度不低于 severity 的命中
+func WithMinSeverity(severity int) ScanOption {
+	return func(o *scanOptions) {
+		o.minSeverity = severity
+	}
+}
+
+// accept 命中的词是否满足分类与严重程度的筛选条件
+func (o scanOptions) accept(node *Node) bool {
+	if node.severity < o.minSeverity {
+		return false
+	}
+	if len(o.categories) == 0 {
+		return true
+	}
+	for _, category := range o.categories {
+		if node.category == category {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSuppressed 将被白名单短语抑制的命中追加到 suppressed 中，便于调整白名单
+func WithSuppressed(suppressed *[]*Match) ScanOption {
+	return func(o *scanOptions) {
+		o.suppressed = suppressed
+	}
+}
+
+func (tree *TrieTree) scanOptions(opts []ScanOption) scanOptions {
+	o := scanOptions{
+		policy:      tree.policy,
+		categories:  tree.categories,
+		minSeverity: tree.minSeverity,
+	}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return o
+}
+
+// selectResults 按匹配策略筛选命中，results 需按起始位置、长度排序
+func selectResults(results []result, policy MatchPolicy) []result {
+	if policy == MatchAll || len(results) == 0 {
+		return results
+	}
+
+	if policy == MatchLeftmostLongest {
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].start != results[j].start {
+				return results[i].start < results[j].start
+			}
+			return results[i].end > results[j].end
+		})
+	}
+
+	selected := results[:0]
+	lastEnd := -1
+	for _, r := range results {
+		if r.start <= lastEnd {
+			continue
+		}
+		selected = append(selected, r)
+		lastEnd = r.end
+	}
+	return selected
+}
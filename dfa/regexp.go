@@ -0,0 +1,111 @@
+package dfa
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// regexpRule 正则规则，与词典一起编译，node 仅用于记录词典词与命中统计
+type regexpRule struct {
+	reg  *regexp.Regexp
+	node *Node
+}
+
+// addRegexp 编译正则规则，同一表达式重复添加时增加引用计数，非法表达式直接忽略，调用方可通过 Entry.Validate 提前校验
+func (tree *TrieTree) addRegexp(entry Entry) {
+	if rule, ok := tree.regexps[entry.Word]; ok {
+		rule.node.refs++
+		return
+	}
+	reg, err := regexp.Compile(entry.Word)
+	if err != nil {
+		return
+	}
+	tree.regexps[entry.Word] = &regexpRule{
+		reg: reg,
+		node: &Node{
+			isEnd:     true,
+			word:      entry.Word,
+			source:    entry.Source,
+			category:  entry.Category,
+			severity:  entry.Severity,
+			replace:   entry.Replacement,
+			wholeWord: entry.WholeWord,
+			refs:      1,
+		},
+	}
+}
+
+// removeRegexp 删除正则规则，不存在时返回 false
+func (tree *TrieTree) removeRegexp(word string) bool {
+	rule, ok := tree.regexps[word]
+	if !ok {
+		return false
+	}
+	rule.node.refs--
+	if rule.node.refs == 0 {
+		delete(tree.regexps, word)
+	}
+	return true
+}
+
+// matchRegexps 在原文上执行正则规则，开启 NFKC 时只统一全角/半角与兼容字符，不做繁简、同音、形近与大小写折叠，
+// 避免数字、汉字被折叠后无法匹配。特殊字符保留在文本中，便于匹配网址等规则
+func (tree *TrieTree) matchRegexps(runes []rune, units []unit) []hit {
+	if len(tree.regexps) == 0 {
+		return nil
+	}
+
+	var (
+		buf strings.Builder
+		// 正则文本中每个字节所属字符的下标
+		owners []int
+		hits   []hit
+		folded = make([]unit, len(runes))
+	)
+	for i, ch := range runes {
+		folded[i] = unit{ch: ch, start: i, end: i}
+	}
+	if tree.nfkc {
+		folded = nfkc(folded)
+	}
+	for position, u := range folded {
+		n, _ := buf.WriteRune(u.ch)
+		for i := 0; i < n; i++ {
+			owners = append(owners, position)
+		}
+	}
+
+	text := buf.String()
+	for _, rule := range tree.regexps {
+		for _, loc := range rule.reg.FindAllStringIndex(text, -1) {
+			// 空匹配没有可替换的字符
+			if loc[0] == loc[1] {
+				continue
+			}
+			start, end := folded[owners[loc[0]]].start, folded[owners[loc[1]-1]].end
+			if (rule.node.wholeWord || tree.wholeWord) && !isWordBoundary(runes, start, end) {
+				continue
+			}
+			// 换算为归一化文本中的下标，便于替换时跳过特殊字符
+			first := sort.Search(len(units), func(i int) bool {
+				return units[i].end >= start
+			})
+			last := sort.Search(len(units), func(i int) bool {
+				return units[i].start > end
+			}) - 1
+			if first > last {
+				continue
+			}
+			hits = append(hits, hit{
+				node:  rule.node,
+				start: start,
+				end:   end,
+				first: first,
+				last:  last,
+			})
+		}
+	}
+	return hits
+}
@@ -0,0 +1,47 @@
+package dfa
+
+import (
+	"sort"
+	"strings"
+)
+
+// span 需要整体替换的原文范围（闭区间）
+type span struct {
+	start int
+	end   int
+	text  string
+}
+
+// applySpans 按起始位置依次替换原文范围，与已替换范围重叠的跳过
+func applySpans(runes []rune, spans []span) string {
+	if len(spans) == 0 {
+		return string(runes)
+	}
+
+	var (
+		builder strings.Builder
+		next    int
+	)
+	builder.Grow(len(runes))
+	for _, s := range sortSpans(spans) {
+		if s.start < next {
+			continue
+		}
+		builder.WriteString(string(runes[next:s.start]))
+		builder.WriteString(s.text)
+		next = s.end + 1
+	}
+	builder.WriteString(string(runes[next:]))
+	return builder.String()
+}
+
+// sortSpans 按起始位置排序，同一起点时较长的范围优先
+func sortSpans(spans []span) []span {
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+	return spans
+}
@@ -0,0 +1,283 @@
+package dfa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 规则表达式的运算符，| 与 OR、& 与 AND、! 与 NOT 等价，优先级从高到低依次为 NOT、AND、OR
+const (
+	ruleAnd = "AND"
+	ruleOr  = "OR"
+	ruleNot = "NOT"
+)
+
+// ruleExpr 规则表达式的语法树，term 不为空时为叶子节点
+type ruleExpr struct {
+	op       string
+	term     string
+	children []*ruleExpr
+}
+
+// rule 编译后的规则，node 用于记录规则名称与命中统计
+type rule struct {
+	expr  *ruleExpr
+	terms []string
+	// 规则中的词归一化后的词，与 terms 一一对应
+	keys []string
+	node *Node
+}
+
+// parseRule 解析规则表达式，如 (枪支|弹药) AND 出售 AND NOT 新闻
+func parseRule(text string) (*ruleExpr, error) {
+	p := &ruleParser{tokens: tokenizeRule(text)}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in rule %q", p.tokens[p.pos], text)
+	}
+	return expr, nil
+}
+
+func tokenizeRule(text string) []string {
+	var (
+		tokens []string
+		term   []rune
+	)
+	flush := func() {
+		if len(term) > 0 {
+			tokens = append(tokens, string(term))
+			term = term[:0]
+		}
+	}
+	for _, ch := range text {
+		switch {
+		case unicode.IsSpace(ch):
+			flush()
+		case ch == '(' || ch == ')' || ch == '|' || ch == '&' || ch == '!':
+			flush()
+			tokens = append(tokens, string(ch))
+		default:
+			term = append(term, ch)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type ruleParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *ruleParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *ruleParser) parseOr() (*ruleExpr, error) {
+	return p.parseBinary(ruleOr, "|", p.parseAnd)
+}
+
+func (p *ruleParser) parseAnd() (*ruleExpr, error) {
+	return p.parseBinary(ruleAnd, "&", p.parseUnary)
+}
+
+func (p *ruleParser) parseBinary(op, symbol string, operand func() (*ruleExpr, error)) (*ruleExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	expr := &ruleExpr{op: op, children: []*ruleExpr{first}}
+	for token := p.peek(); token == op || token == symbol; token = p.peek() {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		expr.children = append(expr.children, next)
+	}
+	if len(expr.children) == 1 {
+		return first, nil
+	}
+	return expr, nil
+}
+
+func (p *ruleParser) parseUnary() (*ruleExpr, error) {
+	switch token := p.peek(); token {
+	case "":
+		return nil, errors.New("unexpected end of rule")
+	case ruleNot, "!":
+		p.pos++
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &ruleExpr{op: ruleNot, children: []*ruleExpr{child}}, nil
+	case "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing ) in rule")
+		}
+		p.pos++
+		return expr, nil
+	case ")", "|", "&", ruleAnd, ruleOr:
+		return nil, fmt.Errorf("unexpected %q in rule", token)
+	default:
+		p.pos++
+		return &ruleExpr{term: token}, nil
+	}
+}
+
+// collectTerms 表达式中出现的所有词
+func (expr *ruleExpr) collectTerms(terms []string) []string {
+	if expr.term != "" {
+		return append(terms, expr.term)
+	}
+	for _, child := range expr.children {
+		terms = child.collectTerms(terms)
+	}
+	return terms
+}
+
+// eval 计算表达式，同时返回使表达式成立的正向词的命中，NOT 下的词不会返回
+func (expr *ruleExpr) eval(termHits map[string][]hit) (bool, []hit) {
+	switch {
+	case expr.term != "":
+		hits := termHits[expr.term]
+		return len(hits) > 0, hits
+	case expr.op == ruleNot:
+		ok, _ := expr.children[0].eval(termHits)
+		return !ok, nil
+	case expr.op == ruleAnd:
+		var hits []hit
+		for _, child := range expr.children {
+			ok, childHits := child.eval(termHits)
+			if !ok {
+				return false, nil
+			}
+			hits = append(hits, childHits...)
+		}
+		return true, hits
+	default:
+		var (
+			matched bool
+			hits    []hit
+		)
+		for _, child := range expr.children {
+			if ok, childHits := child.eval(termHits); ok {
+				matched = true
+				hits = append(hits, childHits...)
+			}
+		}
+		return matched, hits
+	}
+}
+
+// addRule 编译规则，规则中的词加入组合词字典树，由自动机统一查找
+func (tree *TrieTree) addRule(entry Entry) {
+	if r, ok := tree.rules[entry.Word]; ok {
+		r.node.refs++
+		return
+	}
+	expr, err := parseRule(entry.Word)
+	if err != nil {
+		return
+	}
+	name := entry.Name
+	if name == "" {
+		name = strings.TrimSpace(entry.Word)
+	}
+	r := &rule{
+		expr:  expr,
+		terms: expr.collectTerms(nil),
+		node: &Node{
+			isEnd:    true,
+			isRule:   true,
+			word:     name,
+			source:   entry.Source,
+			category: entry.Category,
+			severity: entry.Severity,
+			replace:  entry.Replacement,
+			refs:     1,
+		},
+	}
+	for _, term := range r.terms {
+		tree.addWord(true, Entry{Word: term, WholeWord: entry.WholeWord})
+	}
+	r.keys = tree.termKeys(r.terms)
+	tree.rules[entry.Word] = r
+}
+
+// removeRule 删除规则，不存在时返回 false
+func (tree *TrieTree) removeRule(word string) bool {
+	r, ok := tree.rules[word]
+	if !ok {
+		return false
+	}
+	r.node.refs--
+	if r.node.refs > 0 {
+		return true
+	}
+	delete(tree.rules, word)
+	for _, term := range r.terms {
+		tree.removeWord(true, term)
+	}
+	return true
+}
+
+// matchRules 按组合词字典树的命中计算规则，命中的规则以首个正向词为主命中，其余正向词作为组合片段一并返回
+func (tree *TrieTree) matchRules(comboHits []hit) []result {
+	keyHits := make(map[string][]hit, len(comboHits))
+	for _, h := range comboHits {
+		keyHits[h.node.key] = append(keyHits[h.node.key], h)
+	}
+
+	var results []result
+	for _, r := range tree.rules {
+		termHits := make(map[string][]hit, len(r.terms))
+		for i, term := range r.terms {
+			termHits[term] = keyHits[r.keys[i]]
+		}
+		ok, hits := r.expr.eval(termHits)
+		// 只有否定条件的规则没有可以定位的命中
+		if !ok || len(hits) == 0 {
+			continue
+		}
+		hits = sortHits(uniqueHits(hits))
+		main := hits[0]
+		main.node = r.node
+		results = append(results, result{hit: main, combo: hits[1:]})
+	}
+	return results
+}
+
+func uniqueHits(hits []hit) []hit {
+	type key struct {
+		node       *Node
+		start, end int
+	}
+	var (
+		seen    = make(map[key]struct{}, len(hits))
+		results = hits[:0:0]
+	)
+	for _, h := range hits {
+		k := key{h.node, h.start, h.end}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		results = append(results, h)
+	}
+	return results
+}
@@ -0,0 +1,324 @@
+package dfa
+
+import (
+	"bufio"
+	"io"
+	"sort"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// 默认每次读取的字符数
+	defaultChunkSize = 4096
+	// 默认在块之间保留的字符数
+	defaultKeepSize = 8192
+)
+
+// Scanner 从 io.Reader 流式查找命中，命中的偏移量为在整个输入中的偏移，返回顺序与 FindAll 一致。
+// 文本按块读取，精确匹配的自动机状态在块之间延续，跨块的词依然可以命中；
+// 组合词、规则、正则、间隔、通配符与模糊匹配在最近保留的 keepSize 个字符内查找，
+// 组合词的其他片段尚未出现时暂缓返回，直到超出距离限制或移出保留范围，内存占用只与块大小、保留的字符数相关
+type Scanner struct {
+	tree      *TrieTree
+	reader    *bufio.Reader
+	opts      scanOptions
+	chunkSize int
+	keepSize  int
+	// 末尾暂不处理的字符数，需覆盖最长的词与白名单短语
+	lookahead int
+
+	// 窗口内的原文与归一化文本，offsets 为每个字符在整个输入中的字节偏移，末尾追加窗口的结束位置
+	runes   []rune
+	offsets []int
+	units   []unit
+	// 窗口首个字符在整个输入中的字符偏移
+	base int
+	// 每个参与扫描的词库各自的扫描状态，见 TrieTree.layers
+	cursors []cursor
+	// 已扫描的归一化字符数、已查找完毕的原文字符数，均为窗口内的下标
+	scanned int
+	emitted int
+	// 其他片段尚未出现的组合词，偏移量为窗口内的下标
+	pending []hit
+	// 已确定但尚未返回的命中，需等待之前的命中全部确定后按顺序返回
+	held []*heldMatch
+	// 上一个返回的命中在整个输入中的结束位置，用于保证命中之间不重叠
+	lastEnd int
+
+	matches []*Match
+	match   *Match
+	eof     bool
+	err     error
+}
+
+// heldMatch 已确定但尚未返回的命中
+type heldMatch struct {
+	match *Match
+	node  *Node
+}
+
+// NewScanner 创建流式查找，查找过程中使用创建时的词典
+func (tree *TrieTree) NewScanner(reader io.Reader, opts ...ScanOption) *Scanner {
+	s := &Scanner{
+		tree:      tree,
+		reader:    bufio.NewReader(reader),
+		opts:      tree.scanOptions(opts),
+		chunkSize: defaultChunkSize,
+		keepSize:  defaultKeepSize,
+		offsets:   []int{0},
+	}
+	for _, layer := range tree.layers() {
+		s.lookahead = maxInt(s.lookahead, maxInt(layer.maxDepth, treeDepth(layer.allowRoot))+1)
+		s.cursors = append(s.cursors, cursor{state: layer.root})
+	}
+	return s
+}
+
+// Buffer 设置每次读取的字符数与块之间保留的字符数，需在首次调用 Scan 之前设置，
+// 首尾相距、组合词各片段相距超过 keepSize 个字符的命中可能被忽略，keepSize 不少于最长的词
+func (s *Scanner) Buffer(chunkSize, keepSize int) {
+	s.chunkSize = maxInt(chunkSize, 1)
+	s.keepSize = maxInt(keepSize, s.lookahead)
+}
+
+// Scan 查找下一个命中，读取完毕或出错时返回 false
+func (s *Scanner) Scan() bool {
+	for len(s.matches) == 0 {
+		if s.eof || s.err != nil {
+			return false
+		}
+		s.advance()
+	}
+	s.match, s.matches = s.matches[0], s.matches[1:]
+	return true
+}
+
+// Match 返回 Scan 查找到的命中
+func (s *Scanner) Match() *Match {
+	return s.match
+}
+
+// Err 返回读取过程中的错误，读取完毕不视为错误
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// advance 读取一块文本并查找其中的命中
+func (s *Scanner) advance() {
+	n := len(s.runes)
+	if err := s.read(); err == io.EOF {
+		s.eof = true
+	} else if err != nil {
+		s.err = err
+		return
+	}
+	for _, u := range s.tree.normalize(s.runes[n:]) {
+		s.units = append(s.units, unit{ch: u.ch, start: u.start + n, end: u.end + n})
+	}
+
+	// 未读取完毕时末尾的字符暂不处理，等待后续文本判断单词边界与最长命中
+	to, limit := len(s.units), len(s.runes)
+	if !s.eof {
+		to = s.boundary(len(s.runes) - s.lookahead)
+		if to < len(s.units) {
+			limit = s.units[to].start
+		}
+	}
+
+	var exact []hit
+	for i, layer := range s.tree.layers() {
+		exact = append(exact, layer.scan(&s.cursors[i], s.runes, s.units, s.scanned, to)...)
+	}
+	exact = sortHits(s.tree.live(exact))
+	mark := 0
+	if s.opts.suppressed != nil {
+		mark = len(*s.opts.suppressed)
+	}
+	// 匹配策略在返回时统一处理，避免块的边界影响筛选结果
+	o := s.opts
+	o.policy = MatchAll
+	results, pending := s.tree.collect(s.runes, s.units, exact, s.pending, s.emitted, limit, o)
+	if s.opts.suppressed != nil {
+		for _, match := range (*s.opts.suppressed)[mark:] {
+			s.relocate(match)
+		}
+	}
+	s.hold(results)
+	s.scanned, s.emitted = to, limit
+
+	cut, first := s.cutPoint()
+	s.pending = s.expire(pending, cut)
+	s.commit(s.frontier(limit))
+	if cut > 0 {
+		s.shift(cut, first)
+	}
+}
+
+// read 读取一块文本，不拆分需要整体归一化的组合字符序列
+func (s *Scanner) read() error {
+	for n := 0; ; n++ {
+		ch, size, err := s.reader.ReadRune()
+		if err != nil {
+			return err
+		}
+		if n >= s.chunkSize && (!s.tree.nfkc || norm.NFKC.PropertiesString(string(ch)).BoundaryBefore()) {
+			return s.reader.UnreadRune()
+		}
+		s.runes = append(s.runes, ch)
+		s.offsets = append(s.offsets, s.offsets[len(s.offsets)-1]+size)
+	}
+}
+
+// boundary 返回首个起始位置不小于 limit 的归一化字符，不拆分由同一段原文归一化而来的字符
+func (s *Scanner) boundary(limit int) int {
+	i := s.scanned
+	for i < len(s.units) && s.units[i].start < limit {
+		i++
+	}
+	for i > s.scanned && i < len(s.units) && s.units[i-1].end >= s.units[i].start {
+		i--
+	}
+	return i
+}
+
+// hold 将本次确定的命中转换为在整个输入中的偏移，等待返回
+func (s *Scanner) hold(results []result) {
+	if len(results) == 0 {
+		return
+	}
+
+	var (
+		text    = string(s.runes)
+		offsets = byteOffsets(text)
+	)
+	for _, r := range results {
+		match := newMatch(text, offsets, r.hit)
+		match.Word = r.node.comboWord(match.Word)
+		s.relocate(match)
+		for _, h := range r.combo {
+			combo := newMatch(text, offsets, h)
+			s.relocate(combo)
+			match.Combo = append(match.Combo, combo)
+		}
+		s.held = append(s.held, &heldMatch{match: match, node: r.node})
+	}
+}
+
+// expire 丢弃无法再满足的组合词：读取完毕、即将移出窗口，或之后出现的片段已超出距离限制
+func (s *Scanner) expire(pending []hit, cut int) []hit {
+	kept := pending[:0]
+	for _, p := range pending {
+		if s.eof || p.start < cut {
+			continue
+		}
+		// 之后出现的片段起始位置不早于 next
+		next := s.emitted - s.lookahead
+		if window := s.tree.comboProximity(p.node); window.bounded() && next > p.end &&
+			!window.contains(s.runes, p, hit{start: next, end: next}) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// frontier 起始位置在此之前的命中不会再被后续文本改变，返回在整个输入中的偏移。
+// 之后的命中需覆盖最长的词才能确定同一起点的最长命中，规则的命中可能在保留范围内任意位置出现，
+// 尚未满足的组合词之后的命中需等待其确定后按顺序返回
+func (s *Scanner) frontier(limit int) int {
+	frontier := len(s.runes)
+	if !s.eof {
+		frontier = limit - s.lookahead
+		if s.tree.hasRules() {
+			frontier = minInt(frontier, limit-s.keepSize)
+		}
+	}
+	for _, p := range s.pending {
+		frontier = minInt(frontier, p.start)
+	}
+	return s.base + frontier
+}
+
+// commit 按顺序返回起始位置在 frontier 之前的命中，并按匹配策略筛选
+func (s *Scanner) commit(frontier int) {
+	policy := s.opts.policy
+	sort.SliceStable(s.held, func(i, j int) bool {
+		a, b := s.held[i].match, s.held[j].match
+		if a.RuneStart != b.RuneStart {
+			return a.RuneStart < b.RuneStart
+		}
+		if policy == MatchLeftmostLongest {
+			return a.RuneEnd > b.RuneEnd
+		}
+		return a.RuneEnd < b.RuneEnd
+	})
+
+	i := 0
+	for ; i < len(s.held) && s.held[i].match.RuneStart < frontier; i++ {
+		h := s.held[i]
+		if policy != MatchAll && h.match.RuneStart < s.lastEnd {
+			continue
+		}
+		s.lastEnd = h.match.RuneEnd
+		h.node.incrStats(s.tree.openStats)
+		s.matches = append(s.matches, h.match)
+	}
+	s.held = append(s.held[:0], s.held[i:]...)
+}
+
+// cutPoint 窗口中只保留最近 keepSize 个字符，返回丢弃的原文字符数与归一化字符数，无需丢弃时返回 0
+func (s *Scanner) cutPoint() (int, int) {
+	cut := s.emitted - s.keepSize
+	if cut <= 0 {
+		return 0, 0
+	}
+	first := sort.Search(len(s.units), func(i int) bool {
+		return s.units[i].start >= cut
+	})
+	if first < len(s.units) {
+		return s.units[first].start, first
+	}
+	return len(s.runes), first
+}
+
+// shift 丢弃窗口开头的 cut 个原文字符与 first 个归一化字符
+func (s *Scanner) shift(cut, first int) {
+	// 尚未完成的匹配只保留与当前状态相关的字符，超出保留范围时放弃
+	for i, layer := range s.tree.layers() {
+		c := &s.cursors[i]
+		positions := c.positions[len(c.positions)-c.state.depth:]
+		if len(positions) > 0 && s.units[positions[0]].start <= cut {
+			c.state, positions = layer.root, nil
+		}
+		for j := range positions {
+			positions[j] -= first
+		}
+		c.positions = positions
+	}
+
+	for i := range s.pending {
+		s.pending[i].start -= cut
+		s.pending[i].end -= cut
+		s.pending[i].first -= first
+		s.pending[i].last -= first
+	}
+
+	s.runes = append(s.runes[:0], s.runes[cut:]...)
+	s.offsets = append(s.offsets[:0], s.offsets[cut:]...)
+	s.units = append(s.units[:0], s.units[first:]...)
+	for i := range s.units {
+		s.units[i].start -= cut
+		s.units[i].end -= cut
+	}
+	s.base += cut
+	s.scanned -= first
+	s.emitted -= cut
+}
+
+// relocate 将窗口内的偏移转换为在整个输入中的偏移
+func (s *Scanner) relocate(match *Match) {
+	match.Start, match.End = s.offsets[match.RuneStart], s.offsets[match.RuneEnd]
+	match.RuneStart += s.base
+	match.RuneEnd += s.base
+}
@@ -11,21 +11,95 @@ type TrieTree struct {
 	comboRoot     *Node
 	openStats     bool
 	filterRuneMap map[rune]struct{}
+	policy        MatchPolicy
+	wholeWord     bool
+	caseFold      bool
+	nfkc          bool
+	traditional   bool
+	confusables   map[rune]rune
+	// 替换策略，为空时逐字替换
+	masker Masker
+	// 白名单短语，命中被短语完整覆盖时不再返回
+	allowRoot *Node
+	// 模式节点，存放需要逐字符模拟匹配的词，如允许间隔字符的词
+	patternRoot *Node
+	patterns    map[string]Entry
+	// 全局允许的间隔字符数，仅对不少于 maxGapMinLength 个字符的词生效
+	maxGap          int
+	maxGapMinLength int
+	// 按分类设置的间隔字符数，优先于全局配置
+	categoryGaps map[string]int
+	// 默认只返回这些分类及不低于 minSeverity 的命中，为空表示不限制
+	categories  []string
+	minSeverity int
+	// 是否解析通配符
+	wildcard bool
+	// 正则规则，以表达式为键
+	regexps map[string]*regexpRule
+	// 布尔规则，以表达式为键
+	rules map[string]*rule
+	// 模糊匹配按词长分档允许的编辑次数，仅对拉丁词生效，fuzzyRoot 为其中参与模糊匹配的词
+	fuzzyTiers []FuzzyTier
+	fuzzyRoot  *fuzzyNode
+	fuzzyDepth int
+	// 字典树的最大深度
+	maxDepth int
+	// 汉字转拼音，不为空时开启汉字与拼音混合匹配
+	pinyin PinyinFunc
+	// 组合词各片段之间的默认距离限制
+	comboWindow proximity
+	// 汉字转读音，不为空时开启同音字匹配，读音与其对应的字符一一对应
+	homophone     PinyinFunc
+	syllableRunes map[string]rune
+	syllables     []string
+	// 写时复制的主词库，不为空时当前词库为增量词库，只存放之后新增的词，扫描时与主词库一起扫描；
+	// refs 为主词库中结束节点引用计数的变化，changes 为自上次合并以来的增删，合并时依次应用到主词库的副本
+	base    *TrieTree
+	refs    map[*Node]int
+	changes []change
 }
 
 type Node struct {
 	isRoot    bool
 	isEnd     bool
 	character rune
+	depth     int     // 节点深度，即从根节点到当前节点的字符数
+	gap       int     // 跳跃节点允许跳过的最大字符数
+	gaps      []*Node // 跳跃子节点
+	parents   int     // 模式节点的父节点数，同一步的多个候选汇聚到同一个节点时大于 1
+	word      string  // 结束节点对应的词典词
+	category  string  // 分类
+	severity  int     // 严重程度
+	replace   string  // 替换文本
+	source    string  // 变体对应的原词
+	wholeWord bool    // 是否要求单词边界
+	refs      int     // 引用计数，同一个词被多次添加时需要多次删除
 	words     []string
+	keys      []string  // 组合词其他片段归一化后的词，按 key 查找片段的命中
+	key       string    // 组合词片段、规则中的词归一化后的词，归一化结果相同的词共用同一个节点
+	proximity proximity // 组合词片段之间的距离限制
+	ordered   bool      // 是否为有序组合词
+	isRule    bool      // 是否为规则，规则节点的 word 为规则名称
 	children  map[rune]*Node
+	fail      *Node // 失败指针，指向当前路径的最长后缀节点
+	output    *Node // 输出指针，指向失败链上最近的结束节点
 	hitCount  atomic.Uint64
+	// 被白名单短语抑制的次数
+	suppressedCount atomic.Uint64
 }
 
 // Stats 敏感词统计
 type Stats struct {
-	Word     string
+	Word string
+	// 变体对应的原词，为空表示 Word 即原词
+	Source   string
+	Category string
+	Severity int
 	HitCount uint64
+	// 被白名单短语抑制的次数
+	SuppressedCount uint64
+	// 是否为白名单短语，白名单短语的 HitCount 为其抑制命中的次数
+	Allow bool
 }
 
 func NewTrieTree() *TrieTree {
@@ -40,6 +114,19 @@ func NewTrieTree() *TrieTree {
 			character: '0',
 			children:  make(map[rune]*Node, 0),
 		},
+		patternRoot: &Node{
+			isRoot:    true,
+			character: '0',
+			children:  make(map[rune]*Node, 0),
+		},
+		allowRoot: &Node{
+			isRoot:    true,
+			character: '0',
+			children:  make(map[rune]*Node, 0),
+		},
+		patterns:      map[string]Entry{},
+		regexps:       map[string]*regexpRule{},
+		rules:         map[string]*rule{},
 		filterRuneMap: map[rune]struct{}{},
 	}
 }
@@ -58,27 +145,170 @@ func (tree *TrieTree) WithStats() *TrieTree {
 	return tree
 }
 
+// WithMatchPolicy 默认匹配策略，可通过 WithPolicy 单次覆盖
+func (tree *TrieTree) WithMatchPolicy(policy MatchPolicy) *TrieTree {
+	tree.policy = policy
+	return tree
+}
+
+// WithWholeWord 所有拉丁、西里尔字母及数字组成的词都要求单词边界
+func (tree *TrieTree) WithWholeWord() *TrieTree {
+	tree.wholeWord = true
+	return tree
+}
+
+// WithCaseFold 开启大小写折叠，词典与待检测文本均按 Unicode 完整大小写折叠后匹配
+func (tree *TrieTree) WithCaseFold() *TrieTree {
+	tree.caseFold = true
+	return tree
+}
+
+// WithNFKC 开启 NFKC 及全角/半角归一化，防止使用全角字母、带圈数字等兼容字符绕过
+func (tree *TrieTree) WithNFKC() *TrieTree {
+	tree.nfkc = true
+	return tree
+}
+
+// WithTraditional 开启繁简体等价匹配，词典与待检测文本均折叠为简体后匹配
+func (tree *TrieTree) WithTraditional() *TrieTree {
+	tree.traditional = true
+	return tree
+}
+
+// WithConfusables 开启形近字符折叠，table 为空时使用 DefaultConfusables
+func (tree *TrieTree) WithConfusables(table map[rune]rune) *TrieTree {
+	if table == nil {
+		table = DefaultConfusables()
+	}
+	tree.confusables = table
+	return tree
+}
+
+// WithMaxGap 不少于 minLength 个字符的词，相邻字符之间允许出现最多 gap 个任意字符，
+// 如 丑八怪 可以命中 丑a八b怪，需在添加敏感词之前设置。
+// 允许间隔的词改为逐个起点回溯匹配，不再经过自动机，匹配耗时约为普通词的 35 倍，
+// 词库较大时应调高 minLength 或改用 WithCategoryMaxGap、Entry.MaxGap 只对少量词开启，
+// Entry.MaxGap 小于 0 的词不使用全局配置
+func (tree *TrieTree) WithMaxGap(gap, minLength int) *TrieTree {
+	tree.maxGap = gap
+	tree.maxGapMinLength = minLength
+	return tree
+}
+
+// WithFuzzy 不少于 minLength 个字符的拉丁字母、数字词允许最多 distance 次编辑（增、删、改、相邻交换），
+// 按词长分档时使用 WithFuzzyTiers
+func (tree *TrieTree) WithFuzzy(distance, minLength int) *TrieTree {
+	return tree.WithFuzzyTiers(FuzzyTier{MinLength: minLength, Distance: distance})
+}
+
+// WithCategoryMaxGap 按分类设置相邻字符之间允许出现的最多任意字符数，优先于 WithMaxGap，低于 Entry.MaxGap，
+// 与 WithMaxGap 相同，分类下的词匹配耗时约为普通词的 35 倍，小于 0 时该分类的词不使用全局配置
+func (tree *TrieTree) WithCategoryMaxGap(gaps map[string]int) *TrieTree {
+	tree.categoryGaps = gaps
+	return tree
+}
+
+// WithSeverityFilter 默认只返回 categories 中的分类及严重程度不低于 minSeverity 的命中，单次调用可以通过 WithCategories、WithMinSeverity 覆盖
+func (tree *TrieTree) WithSeverityFilter(categories []string, minSeverity int) *TrieTree {
+	tree.categories = categories
+	tree.minSeverity = minSeverity
+	return tree
+}
+
+// WithMasker 设置 Replace 的替换策略，为空时使用 Replace 传入的字符逐字替换
+func (tree *TrieTree) WithMasker(masker Masker) *TrieTree {
+	tree.masker = masker
+	return tree
+}
+
+// WithWildcard 开启通配符解析，支持 ?、*{m,n} 与 [票漂]，需在添加敏感词之前设置
+func (tree *TrieTree) WithWildcard() *TrieTree {
+	tree.wildcard = true
+	return tree
+}
+
 func (tree *TrieTree) AddWords(words ...string) {
+	entries := make([]Entry, 0, len(words))
 	for _, word := range words {
-		tree.addWord(false, word)
+		entries = append(entries, Entry{Word: word})
 	}
+	tree.AddEntries(entries...)
 }
 
-func (tree *TrieTree) addWord(isCombo bool, word string) {
-	if word == "" {
+// AddEntries 新增带匹配规则的敏感词
+func (tree *TrieTree) AddEntries(entries ...Entry) {
+	tree.record(change{entries: entries})
+	for _, entry := range entries {
+		tree.addEntry(entry)
+	}
+	tree.build()
+}
+
+func (tree *TrieTree) addWord(isCombo bool, entry Entry) {
+	if entry.Word == "" {
+		return
+	}
+	if entry.Regexp && !isCombo {
+		tree.addRegexp(entry)
+		return
+	}
+	if entry.Rule && !isCombo {
+		tree.addRule(entry)
+		return
+	}
+	if entry.Allow && !isCombo {
+		tree.addAllow(entry.Word)
 		return
 	}
+	tree.addSyllables(entry.Word)
 
-	var cur *Node
-	if isCombo {
-		cur = tree.comboRoot
-	} else {
-		cur = tree.root
-	}
-	words := strings.Split(word, "|")
-	characters := []rune(words[0])
-	for position := 0; position < len(characters); position++ {
-		ch := characters[position]
+	var (
+		separator = ComboSeparator(entry.Word)
+		words     = strings.Split(entry.Word, separator)
+		ends      []*Node
+	)
+	if steps, ok := tree.patternSteps(isCombo, entry, words[0]); ok {
+		ends = tree.patternRoot.addPattern(steps)
+		tree.patterns[entry.Word] = entry
+	} else if cur := tree.addLiteral(tree.literalRoot(isCombo), words[0]); cur != nil {
+		ends = []*Node{cur}
+	}
+	// 全部由特殊字符组成
+	if len(ends) == 0 {
+		return
+	}
+
+	for _, cur := range ends {
+		cur.isEnd = true
+		cur.word = words[0]
+		cur.wholeWord = entry.WholeWord
+		cur.category = entry.Category
+		cur.severity = entry.Severity
+		cur.replace = entry.Replacement
+		if cur.refs == 0 {
+			cur.source = entry.Source
+		}
+		cur.refs++
+		if isCombo {
+			cur.key = tree.termKey(words[0])
+		}
+		if len(words) > 1 {
+			cur.words = words[1:]
+			cur.keys = tree.termKeys(words[1:])
+			cur.proximity = proximity{runes: entry.ComboRunes, sentences: entry.ComboSentences}
+			cur.ordered = separator == orderedSeparator
+		}
+	}
+	// 新增组合词
+	for _, word := range words[1:] {
+		tree.addWord(true, Entry{Word: word, WholeWord: entry.WholeWord})
+	}
+}
+
+func (tree *TrieTree) addLiteral(root *Node, word string) *Node {
+	cur := root
+	for _, u := range tree.normalize([]rune(word)) {
+		ch := u.ch
 		if tree.isFilterChar(ch) {
 			continue
 		}
@@ -86,206 +316,312 @@ func (tree *TrieTree) addWord(isCombo bool, word string) {
 			cur = next
 		} else {
 			newNode := NewNode(ch)
+			newNode.depth = cur.depth + 1
 			cur.children[ch] = newNode
 			cur = newNode
 		}
 	}
+	if cur.isRoot {
+		return nil
+	}
+	return cur
+}
 
-	cur.isEnd = true
-	// 新增组合词
-	if len(words) > 1 {
-		cur.words = words[1:]
-		for _, word = range words[1:] {
-			tree.addWord(true, word)
-		}
+// AddAllowWords 新增白名单短语，被短语完整覆盖的命中不再返回，如 sb 在 sbt 中
+func (tree *TrieTree) AddAllowWords(words ...string) {
+	entries := make([]Entry, 0, len(words))
+	for _, word := range words {
+		entries = append(entries, Entry{Word: word, Allow: true})
 	}
+	tree.AddEntries(entries...)
 }
 
-func (tree *TrieTree) detectInCombo(text string, words ...string) ([]int, bool) {
-	var (
-		parent         = tree.comboRoot
-		cur            *Node
-		found          bool
-		runes          = []rune(text)
-		length         = len(runes)
-		left           = 0
-		filterIndexMap = map[int]struct{}{}
-		wordMap        = make(map[string]struct{}, len(words))
-		indexes        []int
-	)
+// RemoveAllowWords 删除白名单短语
+func (tree *TrieTree) RemoveAllowWords(words ...string) {
+	tree.record(change{allows: words})
+	tree.apply(change{allows: words})
+	tree.build()
+}
+
+// RemoveWords 删除敏感词，组合词需与添加时完全一致
+func (tree *TrieTree) RemoveWords(words ...string) {
+	tree.record(change{words: words})
 	for _, word := range words {
-		wordMap[word] = struct{}{}
+		tree.removeEntry(word)
 	}
-	for position := 0; position < length; position++ {
-		ch := runes[position]
-		if tree.isFilterChar(ch) {
-			filterIndexMap[position] = struct{}{}
-			continue
-		}
-		cur, found = parent.children[ch]
+	tree.build()
+}
 
-		if !found || (!cur.IsEnd() && position == length-1) {
-			parent = tree.comboRoot
-			position = left
-			left++
-			continue
-		}
+// removeWord 删除词，词不存在时返回 false
+func (tree *TrieTree) removeWord(isCombo bool, word string) bool {
+	if word == "" {
+		return false
+	}
 
-		if cur.IsEnd() && left <= position {
-			var word []rune
-			var indexCh []int
-			for i := left; i <= position; i++ {
-				// 特殊字符不替换
-				if _, ok := filterIndexMap[i]; ok {
-					continue
-				}
-				word = append(word, runes[i])
-				indexCh = append(indexCh, i)
-			}
-			wordStr := string(word)
-			if _, ok := wordMap[wordStr]; ok {
-				delete(wordMap, wordStr)
-				indexes = append(indexes, indexCh...)
-				if len(wordMap) == 0 {
-					return indexes, true
-				}
+	separator := ComboSeparator(word)
+	words := strings.Split(word, separator)
+	if entry, ok := tree.patterns[word]; ok && !isCombo {
+		steps, _ := tree.patternSteps(isCombo, entry, words[0])
+		ends := tree.patternRoot.findPattern(steps)
+		if len(ends) == 0 || !ends[0].isEnd || !ends[0].sameCombo(separator, words[1:]) {
+			return false
+		}
+		for _, cur := range ends {
+			cur.refs--
+			if cur.refs == 0 {
+				cur.unsetEnd()
 			}
 		}
+		if ends[0].refs > 0 {
+			return true
+		}
+		delete(tree.patterns, word)
+		for _, word = range words[1:] {
+			tree.removeWord(true, word)
+		}
+		tree.patternRoot.prunePattern(steps)
+		return true
+	}
+
+	path := tree.findLiteral(tree.literalRoot(isCombo), words[0])
+	if len(path) == 0 {
+		return false
+	}
+	cur := path[len(path)-1]
+	if cur.isRoot || !cur.isEnd || !cur.sameCombo(separator, words[1:]) {
+		return false
+	}
 
-		parent = cur
+	cur.refs--
+	if cur.refs > 0 {
+		return true
+	}
+	cur.unsetEnd()
+	for _, word = range words[1:] {
+		tree.removeWord(true, word)
 	}
 
-	return nil, false
+	prunePath(path)
+	return true
 }
 
-func (tree *TrieTree) Detect(text string, times int) (bool, []string) {
-	var (
-		parent         = tree.root
-		cur            *Node
-		found          bool
-		runes          = []rune(text)
-		length         = len(runes)
-		left           = 0
-		filterIndexMap = map[int]struct{}{}
-		hitWords       []string
-		isHit          bool
-	)
+func (tree *TrieTree) literalRoot(isCombo bool) *Node {
+	if isCombo {
+		return tree.comboRoot
+	}
+	return tree.root
+}
 
-	for position := 0; position < length; position++ {
-		ch := runes[position]
-		if tree.isFilterChar(ch) {
-			filterIndexMap[position] = struct{}{}
+// findLiteral 查找词在字典树中的路径，不存在时返回空
+func (tree *TrieTree) findLiteral(root *Node, word string) []*Node {
+	cur := root
+	path := []*Node{cur}
+	for _, u := range tree.normalize([]rune(word)) {
+		if tree.isFilterChar(u.ch) {
 			continue
 		}
-		cur, found = parent.children[ch]
-
-		if !found || (!cur.IsEnd() && position == length-1) {
-			parent = tree.root
-			position = left
-			left++
-			continue
+		next, ok := cur.children[u.ch]
+		if !ok {
+			return nil
 		}
+		cur = next
+		path = append(path, cur)
+	}
+	return path
+}
 
-		if cur.IsEnd() && left <= position {
-			var word []rune
-			for i := left; i <= position; i++ {
-				// 特殊字符不替换
-				if _, ok := filterIndexMap[i]; ok {
-					continue
-				}
-				word = append(word, runes[i])
-			}
-			// 组合词的情况下，需要另外处理
-			if len(cur.words) == 0 {
-				isHit = true
-				hitWords = append(hitWords, string(word))
-				times--
-			} else if _, comboHit := tree.detectInCombo(text, cur.words...); comboHit {
-				isHit = true
-				times -= len(cur.words) + 1
-				hitWords = append(hitWords, string(word)+"|"+strings.Join(cur.words, "|"))
-			}
+// prunePath 自底向上清理无用节点
+func prunePath(path []*Node) {
+	for i := len(path) - 1; i > 0; i-- {
+		node := path[i]
+		if node.isEnd || len(node.children) > 0 {
+			break
 		}
+		delete(path[i-1].children, node.character)
+	}
+}
 
-		if isHit {
-			cur.incrStats(tree.openStats)
-		}
+// Clone 深拷贝，用于写时复制，命中统计一并保留。只需增删少量词时使用 CloneAdd、CloneRemove，只重建增量词库
+func (tree *TrieTree) Clone() *TrieTree {
+	clone := tree.clone()
+	clone.build()
+	return clone
+}
 
-		if times <= 0 {
-			return isHit, hitWords
+// clone 深拷贝，不构建失败指针，增量词库与原词库共用主词库
+func (tree *TrieTree) clone() *TrieTree {
+	// 只有模式节点之间存在共用，字典树按树拷贝即可
+	memo := map[*Node]*Node{}
+	clone := tree.config()
+	clone.root = tree.root.clone(nil)
+	clone.comboRoot = tree.comboRoot.clone(nil)
+	clone.patternRoot = tree.patternRoot.clone(memo)
+	clone.allowRoot = tree.allowRoot.clone(nil)
+	for word, entry := range tree.patterns {
+		clone.patterns[word] = entry
+	}
+	// 编译后的正则可并发使用，只需拷贝记录统计的节点
+	for word, rule := range tree.regexps {
+		clone.regexps[word] = &regexpRule{reg: rule.reg, node: rule.node.clone(memo)}
+	}
+	for word, r := range tree.rules {
+		clone.rules[word] = &rule{expr: r.expr, terms: r.terms, keys: r.keys, node: r.node.clone(memo)}
+	}
+	if tree.base != nil {
+		clone.base = tree.base
+		clone.refs = make(map[*Node]int, len(tree.refs))
+		for node, delta := range tree.refs {
+			clone.refs[node] = delta
 		}
-
-		parent = cur
+		// 追加时不能写入原词库的底层数组
+		clone.changes = tree.changes[:len(tree.changes):len(tree.changes)]
 	}
+	return clone
+}
 
-	return times <= 0, hitWords
+// config 返回配置相同的空词库，读音表一并拷贝，保证新增的词与原词库归一化结果一致
+func (tree *TrieTree) config() *TrieTree {
+	var syllableRunes map[string]rune
+	if tree.syllableRunes != nil {
+		syllableRunes = make(map[string]rune, len(tree.syllableRunes))
+		for syllable, ch := range tree.syllableRunes {
+			syllableRunes[syllable] = ch
+		}
+	}
+	clone := NewTrieTree()
+	clone.maxGap = tree.maxGap
+	clone.maxGapMinLength = tree.maxGapMinLength
+	clone.categoryGaps = tree.categoryGaps
+	clone.masker = tree.masker
+	clone.categories = tree.categories
+	clone.minSeverity = tree.minSeverity
+	clone.wildcard = tree.wildcard
+	clone.fuzzyTiers = tree.fuzzyTiers
+	clone.pinyin = tree.pinyin
+	clone.homophone = tree.homophone
+	clone.syllableRunes = syllableRunes
+	clone.syllables = append([]string(nil), tree.syllables...)
+	clone.comboWindow = tree.comboWindow
+	clone.openStats = tree.openStats
+	clone.filterRuneMap = tree.filterRuneMap
+	clone.policy = tree.policy
+	clone.wholeWord = tree.wholeWord
+	clone.caseFold = tree.caseFold
+	clone.nfkc = tree.nfkc
+	clone.traditional = tree.traditional
+	clone.confusables = tree.confusables
+	return clone
 }
 
-func (tree *TrieTree) Replace(text string, replace rune) (bool, string) {
+func (tree *TrieTree) Detect(text string, times int, opts ...ScanOption) (bool, []string) {
 	var (
-		parent         = tree.root
-		cur            *Node
 		runes          = []rune(text)
-		length         = len(runes)
-		left           = 0
-		found          bool
-		isHit          bool
-		filterIndexMap = map[int]struct{}{}
+		results, units = tree.results(runes, tree.scanOptions(opts))
+		hitWords       []string
 	)
 
-	for position := 0; position < len(runes); position++ {
-		ch := runes[position]
-		if tree.isFilterChar(ch) {
-			filterIndexMap[position] = struct{}{}
-			continue
+	for _, r := range results {
+		word := hitWord(runes, tree.hitIndexes(units, r.hit))
+		// 组合词的情况下，需要另外处理
+		if r.node.isRule {
+			// 规则返回规则名称
+			hitWords = append(hitWords, r.node.word)
+			times--
+		} else if len(r.node.words) == 0 {
+			hitWords = append(hitWords, word)
+			times--
+		} else {
+			times -= len(r.node.words) + 1
+			hitWords = append(hitWords, r.node.comboWord(word))
 		}
-		cur, found = parent.children[ch]
 
-		if !found || (!cur.IsEnd() && position == length-1) {
-			parent = tree.root
-			position = left
-			left++
-			continue
-		}
+		r.node.incrStats(tree.openStats)
 
-		if cur.IsEnd() && left <= position {
-			// 组合词的情况下，需要另外处理
-			if len(cur.words) == 0 {
-				isHit = true
-			} else {
-				replaceIndexes, comboHit := tree.detectInCombo(text, cur.words...)
-				if comboHit {
-					isHit = true
-				}
-				for _, i := range replaceIndexes {
-					runes[i] = replace
-				}
-			}
-			if isHit {
-				cur.incrStats(tree.openStats)
-				for i := left; i <= position; i++ {
-					// 特殊字符不替换
-					if _, ok := filterIndexMap[i]; ok {
-						continue
-					}
-					runes[i] = replace
-				}
-			}
+		if times <= 0 {
+			return true, hitWords
 		}
+	}
 
-		parent = cur
+	return times <= 0, hitWords
+}
+
+func (tree *TrieTree) Replace(text string, replace rune, opts ...ScanOption) (bool, string) {
+	var (
+		runes          = []rune(text)
+		offsets        = byteOffsets(text)
+		results, units = tree.results(runes, tree.scanOptions(opts))
+		masker         = tree.masker
+		masked         []hit
+		spans          []span
+	)
+	if masker == nil {
+		masker = RuneMasker(replace)
+	}
+
+	for _, r := range results {
+		r.node.incrStats(tree.openStats)
+		hits := append([]hit{r.hit}, r.combo...)
+		// 设置了替换文本的词整体替换，组合词的其他片段仍按替换策略处理
+		if r.node.replace != "" {
+			spans = append(spans, span{start: r.start, end: r.end, text: r.node.replace})
+			hits = r.combo
+		}
+		masked = append(masked, hits...)
 	}
+	spans = append(spans, tree.mask(text, offsets, runes, units, masked, masker)...)
 
-	return isHit, string(runes)
+	return len(results) > 0, applySpans(runes, spans)
 }
 
 func (tree *TrieTree) DebugInfos() []*Stats {
-	node := tree.root
-	if node == nil {
+	if tree.root == nil {
 		return nil
 	}
+	results := tree.debugInfos(tree.alive)
+	if tree.base != nil {
+		results = append(results, tree.base.debugInfos(tree.alive)...)
+	}
+	return results
+}
 
-	return mapDeepRange([]*Stats{}, "", node.children)
+// debugInfos 返回 alive 为真的词的统计，写时复制的副本过滤主词库中已经删除的词
+func (tree *TrieTree) debugInfos(alive func(*Node) bool) []*Stats {
+	visited := map[*Node]struct{}{}
+	results := mapDeepRange([]*Stats{}, tree.root.children, visited, alive)
+	// 拆分后的模式词有多个结束节点，按词合并
+	results = append(results, mergeStats(mapDeepRange(nil, tree.patternRoot.children, visited, alive))...)
+	for _, rule := range tree.regexps {
+		if !alive(rule.node) {
+			continue
+		}
+		results = append(results, &Stats{
+			Word:            rule.node.word,
+			Source:          rule.node.source,
+			Category:        rule.node.category,
+			Severity:        rule.node.severity,
+			HitCount:        rule.node.hitCount.Load(),
+			SuppressedCount: rule.node.suppressedCount.Load(),
+		})
+	}
+	allowStart := len(results)
+	results = mapDeepRange(results, tree.allowRoot.children, visited, alive)
+	for _, stats := range results[allowStart:] {
+		stats.Allow = true
+	}
+	for _, r := range tree.rules {
+		if !alive(r.node) {
+			continue
+		}
+		results = append(results, &Stats{
+			Word:            r.node.word,
+			Source:          r.node.source,
+			Category:        r.node.category,
+			Severity:        r.node.severity,
+			HitCount:        r.node.hitCount.Load(),
+			SuppressedCount: r.node.suppressedCount.Load(),
+		})
+	}
+	return results
 }
 
 func (tree *TrieTree) isFilterChar(ch rune) bool {
@@ -299,6 +635,8 @@ func (tree *TrieTree) isFilterChar(ch rune) bool {
 	switch {
 	case unicode.Is(unicode.Han, ch): // 汉字
 		return false
+	case isSyllableRune(ch): // 同音字折叠后的读音
+		return false
 	case unicode.IsLetter(ch): // 字母
 		return false
 	case unicode.IsDigit(ch): // 数字
@@ -314,6 +652,63 @@ func NewNode(character rune) *Node {
 	}
 }
 
+// clone 拷贝节点及其子节点，memo 用于共用节点只拷贝一次，为空时按树拷贝。
+// 只有模式节点下存在共用节点，如同时出现在 children 与 gaps 中的跳跃节点、多个候选汇聚的节点，其他字典树可以不传 memo
+func (node *Node) clone(memo map[*Node]*Node) *Node {
+	if clone, ok := memo[node]; ok {
+		return clone
+	}
+	clone := &Node{
+		isRoot:    node.isRoot,
+		isEnd:     node.isEnd,
+		character: node.character,
+		depth:     node.depth,
+		gap:       node.gap,
+		parents:   node.parents,
+		word:      node.word,
+		category:  node.category,
+		severity:  node.severity,
+		replace:   node.replace,
+		source:    node.source,
+		wholeWord: node.wholeWord,
+		refs:      node.refs,
+		words:     node.words,
+		keys:      node.keys,
+		key:       node.key,
+		proximity: node.proximity,
+		ordered:   node.ordered,
+		isRule:    node.isRule,
+		children:  make(map[rune]*Node, len(node.children)),
+	}
+	if memo != nil {
+		memo[node] = clone
+	}
+	clone.hitCount.Store(node.hitCount.Load())
+	clone.suppressedCount.Store(node.suppressedCount.Load())
+	for ch, child := range node.children {
+		clone.children[ch] = child.clone(memo)
+	}
+	for _, gap := range node.gaps {
+		clone.gaps = append(clone.gaps, gap.clone(memo))
+	}
+	return clone
+}
+
+func (node *Node) unsetEnd() {
+	node.isEnd = false
+	node.word = ""
+	node.category = ""
+	node.severity = 0
+	node.replace = ""
+	node.source = ""
+	node.wholeWord = false
+	node.words = nil
+	node.keys = nil
+	node.key = ""
+	node.proximity = proximity{}
+	node.ordered = false
+}
+
 func (node *Node) IsEnd() bool {
 	return node.isEnd
 }
@@ -325,20 +720,55 @@ func (node *Node) incrStats(openStats bool) {
 	node.hitCount.Inc()
 }
 
-func mapDeepRange(results []*Stats, word string, maps map[rune]*Node) []*Stats {
-	for ch, cur := range maps {
-		currentWord := word
-		currentWord += string(ch)
+func equalWords(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeStats 合并同一个词的命中统计
+func mergeStats(stats []*Stats) []*Stats {
+	var (
+		merged []*Stats
+		index  = map[string]*Stats{}
+	)
+	for _, s := range stats {
+		if m, ok := index[s.Word]; ok {
+			m.HitCount += s.HitCount
+			m.SuppressedCount += s.SuppressedCount
+			continue
+		}
+		index[s.Word] = s
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func mapDeepRange(results []*Stats, maps map[rune]*Node, visited map[*Node]struct{}, alive func(*Node) bool) []*Stats {
+	for _, cur := range maps {
+		if _, ok := visited[cur]; ok {
+			continue
+		}
+		visited[cur] = struct{}{}
 		if cur.children != nil {
-			results = mapDeepRange(results, currentWord, cur.children)
+			results = mapDeepRange(results, cur.children, visited, alive)
 		}
-		if cur.IsEnd() {
-			if len(cur.words) > 0 {
-				currentWord += "|" + strings.Join(cur.words, "|")
-			}
+		if cur.IsEnd() && alive(cur) {
+			// 使用词典中的原词，归一化后的路径可能与原词不同
+			currentWord := cur.comboWord(cur.word)
 			results = append(results, &Stats{
-				Word:     currentWord,
-				HitCount: cur.hitCount.Load(),
+				Word:            currentWord,
+				Source:          cur.source,
+				Category:        cur.category,
+				Severity:        cur.severity,
+				HitCount:        cur.hitCount.Load(),
+				SuppressedCount: cur.suppressedCount.Load(),
 			})
 		}
 	}
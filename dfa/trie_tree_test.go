@@ -1,8 +1,12 @@
 package dfa
 
 import (
-	"github.com/go-playground/assert/v2"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
+
+	"github.com/go-playground/assert/v2"
 )
 
 func TestFilterChar(t *testing.T) {
@@ -99,3 +103,1304 @@ func TestReplace(t *testing.T) {
 		assert.Equal(t, hitWord, want.word)
 	}
 }
+
+func TestDetectOverlap(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddWords([]string{
+		"abcd", "bc", "c", "她妈", "他妈的",
+	}...)
+
+	// 失败指针回退后仍能命中后缀词
+	isHit, hitWords := tree.Detect("xabcdx", 3)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"abcd", "bc", "c"})
+
+	isHit, hitWords = tree.Detect("他妈-的", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"他妈的"})
+
+	isHit, hitWords = tree.Detect("他她妈", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"她妈"})
+
+	isHit, newText := tree.Replace("xab-cdx", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "x**-**x")
+}
+
+func TestRemoveWords(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddWords([]string{
+		"傻逼", "傻逼啊", "垃圾", "司马南|美国",
+	}...)
+
+	clone := tree.Clone()
+	clone.RemoveWords("傻逼", "司马南|美国", "不存在")
+
+	isHit, hitWords := clone.Detect("你是傻逼啊", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"傻逼啊"})
+
+	isHit, _ = clone.Detect("司马南在美国", 1)
+	assert.Equal(t, isHit, false)
+
+	// 原词库不受影响
+	isHit, hitWords = tree.Detect("你是傻逼", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"傻逼"})
+
+	// 多次添加需要多次删除
+	clone.AddWords("垃圾")
+	clone.RemoveWords("垃圾")
+	isHit, _ = clone.Detect("垃圾", 1)
+	assert.Equal(t, isHit, true)
+	clone.RemoveWords("垃圾")
+	isHit, _ = clone.Detect("垃圾", 1)
+	assert.Equal(t, isHit, false)
+
+	// 拷贝与增删合并为一步，原词库不受影响
+	added := tree.CloneAdd(Entry{Word: "丑八怪"})
+	isHit, _ = added.Detect("你个丑八怪", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = tree.Detect("你个丑八怪", 1)
+	assert.Equal(t, isHit, false)
+	removed := added.CloneRemove("傻逼")
+	isHit, hitWords = removed.Detect("你是傻逼啊", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"傻逼啊"})
+	isHit, hitWords = added.Detect("你是傻逼", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"傻逼"})
+}
+
+func TestCloneDelta(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithStats()
+	tree.AddEntries([]Entry{
+		{Word: "傻逼"}, {Word: "垃圾"}, {Word: "垃圾"}, {Word: "司马南|美国"},
+		{Word: "丑八怪", MaxGap: 1},
+		{Word: `1[3-9]\d{9}`, Regexp: true},
+		{Word: "枪支 AND 出售", Rule: true},
+		{Word: "sbt", Allow: true},
+		{Word: "sb"},
+	}...)
+
+	// 增删只重建增量词库，主词库与原词库共用
+	removed := tree.CloneRemove("垃圾", "傻逼", "司马南|美国", "丑八怪", `1[3-9]\d{9}`, "枪支 AND 出售", "不存在")
+	assert.Equal(t, removed.base, tree)
+	for text, want := range map[string]bool{
+		"垃圾":          true,
+		"傻逼":          false,
+		"司马南在美国":      false,
+		"丑a八b怪":       false,
+		"13812345678": false,
+		"出售枪支":        false,
+	} {
+		isHit, _ := removed.Detect(text, 1)
+		assert.Equal(t, isHit, want)
+		isHit, _ = tree.Detect(text, 1)
+		assert.Equal(t, isHit, true)
+	}
+
+	// 多次添加需要多次删除，删除后可以重新添加
+	again := removed.CloneRemove("垃圾", "垃圾")
+	isHit, _ := again.Detect("垃圾", 1)
+	assert.Equal(t, isHit, false)
+	again = again.CloneAdd(Entry{Word: "垃圾"}, Entry{Word: "傻逼"}, Entry{Word: "丑八怪", MaxGap: 1})
+	for _, text := range []string{"垃圾", "傻逼", "丑a八b怪"} {
+		isHit, _ = again.Detect(text, 1)
+		assert.Equal(t, isHit, true)
+	}
+	assert.Equal(t, again.base, tree)
+
+	// 新增的词与主词库一起扫描，白名单同时作用于两者
+	added := again.CloneAdd(Entry{Word: "丑八"}, Entry{Word: "sbx"}, Entry{Word: "sbx", Allow: true}, Entry{Word: "出售|枪支"})
+	for text, want := range map[string][]string{
+		"你个丑a八b怪":     {"丑八怪"},
+		"你个丑八":        {"丑八"},
+		"sbt 和 sbx":   nil,
+		"sb 和 sbx":    {"sb"},
+		"出售枪支":        {"出售|枪支"},
+		"傻逼说垃圾":       {"傻逼", "垃圾"},
+		"13812345678": nil,
+	} {
+		var words []string
+		for _, m := range added.FindAll(text) {
+			words = append(words, m.Word)
+		}
+		assert.Equal(t, words, want)
+	}
+	isHit, newText := added.Replace("你个傻逼，丑八", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你个**，**")
+	streamed := 0
+	scanner := added.NewScanner(strings.NewReader(strings.Repeat("傻逼，丑八，", 100)))
+	scanner.Buffer(4, 16)
+	for scanner.Scan() {
+		streamed++
+	}
+	assert.Equal(t, streamed, 200)
+
+	// 统计不包含已删除的词，增量词库的统计在重建后保留
+	stats := map[string]uint64{}
+	for _, s := range added.CloneAdd(Entry{Word: "王八蛋"}).DebugInfos() {
+		stats[s.Word] = s.HitCount
+	}
+	_, ok := stats["司马南|美国"]
+	assert.Equal(t, ok, false)
+	assert.Equal(t, stats["丑八"], uint64(102))
+	assert.Equal(t, stats["王八蛋"], uint64(0))
+
+	// 累计的增删超过 mergeThreshold 时合并为新的主词库
+	merged := added
+	for i := 0; i <= mergeThreshold; i++ {
+		merged = merged.CloneAdd(Entry{Word: strings.Repeat("滚", i%8+2)})
+	}
+	assert.Equal(t, merged.base != tree, true)
+	for text, want := range map[string]bool{
+		"滚滚":    true,
+		"丑八":    true,
+		"傻逼":    true,
+		"司马南美国": false,
+	} {
+		isHit, _ = merged.Detect(text, 1)
+		assert.Equal(t, isHit, want)
+	}
+	stats = map[string]uint64{}
+	for _, s := range merged.DebugInfos() {
+		stats[s.Word] = s.HitCount
+	}
+	assert.Equal(t, stats["丑八"], uint64(103))
+	_, ok = stats["司马南|美国"]
+	assert.Equal(t, ok, false)
+}
+
+func TestPrunePattern(t *testing.T) {
+	readings := map[rune][]string{'丑': {"chou"}, '八': {"ba"}, '怪': {"guai"}, '臭': {"chou"}, '虫': {"chong"}}
+	tree := NewTrieTree()
+	tree.WithMaxGap(1, 2)
+	tree.WithPinyin(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.AddWords("丑八怪")
+	count := countNodes(tree.patternRoot, map[*Node]struct{}{})
+
+	// 删除后不再通往任何结束节点的模式节点一并删除，共用的前缀保留
+	tree.AddWords("丑八", "丑怪")
+	tree.RemoveWords("丑八", "丑怪")
+	assert.Equal(t, countNodes(tree.patternRoot, map[*Node]struct{}{}), count)
+
+	// 拆分出的节点仍通往 丑八怪 的结束节点，反复增删不再增加节点
+	tree.AddWords("臭虫")
+	tree.RemoveWords("臭虫")
+	count = countNodes(tree.patternRoot, map[*Node]struct{}{})
+	for i := 0; i < 3; i++ {
+		tree.AddWords("臭虫", "丑八")
+		tree.RemoveWords("臭虫", "丑八")
+	}
+	assert.Equal(t, countNodes(tree.patternRoot, map[*Node]struct{}{}), count)
+	isHit, _ := tree.Detect("chou八a怪", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = tree.Detect("臭虫", 1)
+	assert.Equal(t, isHit, false)
+
+	tree.RemoveWords("丑八怪")
+	assert.Equal(t, len(tree.patternRoot.children), 0)
+}
+
+// countNodes 统计节点数，汇聚节点只统计一次
+func countNodes(node *Node, visited map[*Node]struct{}) int {
+	if _, ok := visited[node]; ok {
+		return 0
+	}
+	visited[node] = struct{}{}
+	count := 1
+	for _, child := range node.children {
+		count += countNodes(child, visited)
+	}
+	return count
+}
+
+func TestFindAll(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddWords([]string{
+		"垃圾", "bad", "司马南|美国",
+	}...)
+
+	matches := tree.FindAll("你是垃--圾, so b-a-d")
+	assert.Equal(t, len(matches), 2)
+	assert.Equal(t, *matches[0], Match{
+		Word: "垃圾", Text: "垃--圾", Start: 6, End: 14, RuneStart: 2, RuneEnd: 6,
+	})
+	assert.Equal(t, *matches[1], Match{
+		Word: "bad", Text: "b-a-d", Start: 19, End: 24, RuneStart: 11, RuneEnd: 16,
+	})
+
+	matches = tree.FindAll("司马南在美国")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "司马南|美国")
+	assert.Equal(t, matches[0].Text, "司马南")
+	assert.Equal(t, len(matches[0].Combo), 1)
+	assert.Equal(t, *matches[0].Combo[0], Match{
+		Word: "美国", Text: "美国", Start: 12, End: 18, RuneStart: 4, RuneEnd: 6,
+	})
+
+	assert.Equal(t, len(tree.FindAll("司马南在中国")), 0)
+}
+
+func TestMatchPolicy(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddWords([]string{
+		"丑八", "丑八怪", "八怪", "怪物",
+	}...)
+
+	isHit, hitWords := tree.Detect("你这个丑八怪物", 4)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"丑八", "丑八怪", "八怪", "怪物"})
+
+	isHit, hitWords = tree.Detect("你这个丑八怪物", 2, WithPolicy(MatchLeftmostLongest))
+	assert.Equal(t, isHit, false)
+	assert.Equal(t, hitWords, []string{"丑八怪"})
+
+	isHit, hitWords = tree.Detect("你这个丑八怪物", 2, WithPolicy(MatchLeftmostShortest))
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords, []string{"丑八", "怪物"})
+
+	tree.WithMatchPolicy(MatchLeftmostLongest)
+	isHit, newText := tree.Replace("丑八怪物", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "***物")
+
+	matches := tree.FindAll("丑八怪物", WithPolicy(MatchLeftmostShortest))
+	assert.Equal(t, len(matches), 2)
+	assert.Equal(t, matches[0].Word, "丑八")
+	assert.Equal(t, matches[1].Word, "怪物")
+}
+
+func TestWholeWord(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddEntries([]Entry{
+		{Word: "ass", WholeWord: true},
+		{Word: "сука", WholeWord: true},
+		{Word: "a片", WholeWord: true},
+		{Word: "fuck"},
+		{Word: "傻逼", WholeWord: true},
+	}...)
+
+	for text, hit := range map[string]bool{
+		"kiss my ass":    true,
+		"ass-hole":       true,
+		"你个ass":          true,
+		"first class":    false,
+		"an assistant":   false,
+		"a.s.s":          true,
+		"ты сукаблять":   false,
+		"ты сука, блять": true,
+		"看a片":            true,
+		"看aa片":           false,
+		"motherfucker":   true,
+		"大傻逼们":           true,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+
+	tree.WithWholeWord()
+	isHit, _ := tree.Detect("motherfucker", 1)
+	assert.Equal(t, isHit, false)
+}
+
+func TestCaseFold(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithCaseFold()
+	tree.AddWords([]string{
+		"fuck", "ChouBi", "STRASSE", "сука",
+	}...)
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"FUCK you":     {true, "FUCK"},
+		"Fuck you":     {true, "Fuck"},
+		"choubi":       {true, "choubi"},
+		"CHOU-BI":      {true, "CHOUBI"},
+		"straße":       {true, "straße"},
+		"СУКА":         {true, "СУКА"},
+		"nothing here": {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	isHit, newText := tree.Replace("Die Straße, FuCk", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "Die ******, ****")
+
+	matches := tree.FindAll("Die Straße")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "STRASSE")
+	assert.Equal(t, matches[0].Text, "Straße")
+}
+
+func TestNFKC(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithNFKC().WithCaseFold()
+	tree.AddWords([]string{
+		"fuck", "123", "café", "ｶﾞｲｼﾞﾝ",
+	}...)
+
+	for text, hit := range map[string]bool{
+		"ｆｕｃｋ":  true,
+		"ＦＵＣＫ":  true,
+		"①②③":   true,
+		"café": true,
+		"ガイジン":  true,
+		"fu ck": true,
+		"fork":  false,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, newText := tree.Replace("你ｆｕ-ｃｋ①②③", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你**-*****")
+
+	isHit, newText = tree.Replace("a café.", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "a *****.")
+
+	matches := tree.FindAll("ｆｕｃｋ")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Text, "ｆｕｃｋ")
+	assert.Equal(t, matches[0].End, len("ｆｕｃｋ"))
+}
+
+func TestTraditional(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithTraditional()
+	tree.AddWords([]string{
+		"丑八怪", "髮廊|服務",
+	}...)
+
+	for text, hit := range map[string]bool{
+		"你這個醜八怪":   true,
+		"你这个丑八怪":   true,
+		"你這個醜八":    false,
+		"发廊提供特殊服务": true,
+		"髮廊提供特殊服務": true,
+		"发廊提供理发":   false,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, newText := tree.Replace("你這個醜八怪", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你這個***")
+
+	matches := tree.FindAll("你這個醜八怪")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "丑八怪")
+	assert.Equal(t, matches[0].Text, "醜八怪")
+}
+
+func TestConfusables(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithConfusables(nil).WithCaseFold()
+	tree.AddWords([]string{
+		"shit", "丑八怪",
+	}...)
+
+	for text, hit := range map[string]bool{
+		"sh1t": true,
+		"$hit": true,
+		"ѕhit": true, // 西里尔字母 dze
+		"ЅНIT": true, // 西里尔大写字母
+		"5H!T": true,
+		"shot": false,
+		"丑八怪":  true,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, newText := tree.Replace("oh $h1t!", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "oh ****!")
+
+	custom := NewTrieTree()
+	custom.WithConfusables(map[rune]rune{'ㄅ': 'b'})
+	custom.AddWords("bad")
+	isHit, _ = custom.Detect("ㄅad", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = custom.Detect("b4d", 1)
+	assert.Equal(t, isHit, false)
+}
+
+func TestMaxGap(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddEntries([]Entry{
+		{Word: "丑八怪", MaxGap: 2},
+		{Word: "傻逼"},
+		{Word: "代开发票|联系", MaxGap: 1},
+	}...)
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"丑八怪":        {true, "丑八怪"},
+		"丑a八b怪":      {true, "丑a八b怪"},
+		"你丑了八个怪":     {true, "丑了八个怪"},
+		"丑--了八个-怪":   {true, "丑了八个怪"},
+		"丑了又了八怪":     {false, ""},
+		"傻x逼":        {false, ""},
+		"代x开x发x票请联系": {true, "代x开x发x票|联系"},
+		"代x开x发x票":    {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	isHit, newText := tree.Replace("你丑了-八个怪", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你**-***")
+
+	matches := tree.FindAll("你丑了-八个怪")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "丑八怪")
+	assert.Equal(t, matches[0].Text, "丑了-八个怪")
+
+	// 全局配置仅对足够长的词生效
+	global := NewTrieTree()
+	global.WithMaxGap(1, 3)
+	global.AddWords("丑八怪", "傻逼")
+	isHit, _ = global.Detect("丑a八b怪", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = global.Detect("傻a逼", 1)
+	assert.Equal(t, isHit, false)
+
+	// MaxGap 小于 0 的词不使用全局配置
+	global.AddEntries(Entry{Word: "王八蛋", MaxGap: -1})
+	isHit, _ = global.Detect("王a八b蛋", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = global.Detect("王八蛋", 1)
+	assert.Equal(t, isHit, true)
+
+	// 删除与拷贝
+	clone := tree.Clone()
+	clone.RemoveWords("丑八怪", "代开发票|联系")
+	isHit, _ = clone.Detect("丑a八b怪", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = clone.Detect("代开发票请联系", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = tree.Detect("丑a八b怪", 1)
+	assert.Equal(t, isHit, true)
+}
+
+func TestWildcard(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithWildcard()
+	tree.AddWords([]string{
+		"代*{0,3}开发[票漂]", "微?信", "加*{1,2}群", "卖[枪槍]*",
+	}...)
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"代开发票":     {true, "代开发票"},
+		"代理可以开发漂":  {true, "代理可以开发漂"},
+		"代我们一起开发票": {false, ""},
+		"代开发据":     {false, ""},
+		"加我微x信":    {true, "微x信"},
+		"加我微信":     {false, ""},
+		"快来加我的群":   {true, "加我的群"},
+		"快来加群":     {false, ""},
+		"卖槍":       {true, "卖槍"},
+		"代-开-发-票":  {true, "代开发票"},
+		"代（正规）开发票": {true, "代正规开发票"},
+		"代开发[票]":   {true, "代开发票"},
+		"什么?都不是微信": {false, ""},
+		"代一二三四开发票": {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	isHit, newText := tree.Replace("找我代（正规）开发票", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "找我*（**）***")
+
+	steps := tree.parseWildcard("a*{2,5}b[cd]?*")
+	assert.Equal(t, len(steps), 6)
+	assert.Equal(t, steps[3].gap, 3)
+	assert.Equal(t, steps[4].alternatives, [][]rune{{'c'}, {'d'}})
+	assert.Equal(t, steps[5].alternatives, [][]rune{{anyRune}})
+
+	// 未开启通配符时按特殊字符处理
+	plain := NewTrieTree()
+	plain.AddWords("微?信")
+	isHit, _ = plain.Detect("微信", 1)
+	assert.Equal(t, isHit, true)
+}
+
+func TestRegexp(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithStats()
+	tree.AddWords("丑八怪")
+	tree.AddEntries(
+		Entry{Word: "[一二三四五六七八九零〇]{11}", Regexp: true},
+		Entry{Word: "(https?://[a-z.]+/? *){2,}", Regexp: true},
+		Entry{Word: "^(a|b)$", Regexp: true},
+	)
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"电话一三八零零一三八零零零":             {true, "一三八零零一三八零零零"},
+		"电话一三八零零":                   {false, ""},
+		"http://a.com http://b.com": {true, "httpacomhttpbcom"},
+		"http://a.com":              {false, ""},
+		"b":                         {true, "b"},
+		"你个丑八怪":                     {true, "丑八怪"},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	isHit, newText := tree.Replace("加我一三八零零一三八零零零丑八怪", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "加我**************")
+
+	stats := map[string]uint64{}
+	for _, s := range tree.DebugInfos() {
+		stats[s.Word] = s.HitCount
+	}
+	assert.Equal(t, stats["[一二三四五六七八九零〇]{11}"], uint64(2))
+	assert.Equal(t, stats["^(a|b)$"], uint64(1))
+
+	// 正则与普通词使用同一份统计，写时复制后仍保留
+	clone := tree.Clone()
+	clone.RemoveWords("^(a|b)$")
+	isHit, _ = clone.Detect("b", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = tree.Detect("b", 1)
+	assert.Equal(t, isHit, true)
+
+	// 正则不受形近、同音与大小写折叠影响
+	tree = NewTrieTree()
+	tree.WithNFKC().WithConfusables(nil).WithCaseFold()
+	tree.WithHomophone(func(ch rune) []string {
+		return map[rune][]string{'一': {"yi"}, '三': {"san"}, '八': {"ba"}, '零': {"ling"}}[ch]
+	})
+	tree.AddWords("三八")
+	tree.AddEntries(
+		Entry{Word: `1[3-9]\d{9}`, Regexp: true},
+		Entry{Word: "[一二三四五六七八九零〇]{5,}", Regexp: true},
+	)
+	for text, want := range map[string]string{
+		"加我13800138000": "13800138000",
+		"加我１３８００１３８０００": "１３８００１３８０００",
+		"加我一三八零零":       "一三八零零",
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, hitWords[0], want)
+	}
+	isHit, newText = tree.Replace("加我１３８００１３８０００", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "加我***********")
+
+	assert.NotEqual(t, Entry{Word: "(", Regexp: true}.Validate(), nil)
+	assert.Equal(t, Entry{Word: "(", Regexp: false}.Validate(), nil)
+}
+
+func TestFuzzy(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithFuzzy(1, 4)
+	tree.AddWords("fuck", "choubi", "sb", "丑八怪")
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"fcuk you":     {true, "fcuk"},
+		"fukc":         {true, "fukc"},
+		"fuk":          {true, "fuk"},
+		"f-u-c-c":      {true, "fucc"},
+		"fxxk":         {false, ""},
+		"choubii":      {true, "choubi"},
+		"chobi":        {true, "chobi"},
+		"sx":           {false, ""},
+		"丑八x":          {false, ""},
+		"nothing here": {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	matches := tree.FindAll("fuck and fcuk")
+	assert.Equal(t, len(matches), 2)
+	assert.Equal(t, matches[0].Distance, 0)
+	assert.Equal(t, matches[1].Word, "fuck")
+	assert.Equal(t, matches[1].Text, "fcuk")
+	assert.Equal(t, matches[1].Distance, 1)
+
+	isHit, newText := tree.Replace("oh fcuk!", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "oh ****!")
+
+	// 只从单词开头查找
+	for text, hit := range map[string]bool{
+		"你fcuk":  true,
+		"a-fcuk": true,
+		"afcuk":  false,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+
+	// 按词长分档
+	tiers := NewTrieTree()
+	tiers.WithFuzzyTiers(FuzzyTier{MinLength: 8, Distance: 2}, FuzzyTier{MinLength: 4, Distance: 1})
+	tiers.AddWords("fuck", "motherfucker", "sb")
+	for text, distance := range map[string]int{
+		"fcuk":         1,
+		"fxxk":         -1,
+		"mohterfcuker": 2,
+		"mohterfcukre": -1,
+		"sx":           -1,
+	} {
+		matches := tiers.FindAll(text)
+		if distance < 0 {
+			assert.Equal(t, len(matches), 0)
+			continue
+		}
+		assert.Equal(t, len(matches), 1)
+		assert.Equal(t, matches[0].Distance, distance)
+	}
+
+	// 未开启时只做精确匹配
+	plain := NewTrieTree()
+	plain.AddWords("fuck")
+	isHit, _ = plain.Detect("fcuk", 1)
+	assert.Equal(t, isHit, false)
+}
+
+func TestPinyinMixed(t *testing.T) {
+	readings := map[rune][]string{'丑': {"chou"}, '八': {"ba"}, '怪': {"guai"}, '女': {"nv"}}
+	tree := NewTrieTree()
+	tree.WithPinyin(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.AddWords("丑八怪", "丑女", "sb")
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"你个丑ba怪":     {true, "丑ba怪"},
+		"chou八guai":  {true, "chou八guai"},
+		"choubaguai": {true, "choubaguai"},
+		"丑八怪":        {true, "丑八怪"},
+		"chou-nv":    {true, "chounv"},
+		"丑ba":        {false, ""},
+		"choubaguoi": {false, ""},
+		"说sb":        {true, "sb"},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	matches := tree.FindAll("你个chou八guai")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "丑八怪")
+	assert.Equal(t, matches[0].RuneStart, 2)
+	assert.Equal(t, matches[0].RuneEnd, 11)
+
+	isHit, newText := tree.Replace("你个丑ba怪", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你个****")
+
+	// 汉字与拼音汇聚到同一个节点，删除后两条路径都不再命中
+	tree.RemoveWords("丑八怪")
+	isHit, _ = tree.Detect("丑ba怪", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = tree.Detect("chou女", 1)
+	assert.Equal(t, isHit, true)
+
+	// 同音的字不共用读音之后的节点
+	readings['臭'], readings['虫'] = []string{"chou"}, []string{"chong"}
+	tree = NewTrieTree()
+	tree.WithStats()
+	tree.WithPinyin(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.AddWords("丑八", "臭虫", "丑八")
+	for text, want := range map[string]string{
+		"丑虫":      "",
+		"臭八":      "",
+		"丑八":      "丑八",
+		"chouba":  "丑八",
+		"臭chong":  "臭虫",
+		"chou虫":   "臭虫",
+		"chou八":   "丑八",
+		"choubaa": "丑八",
+	} {
+		matches := tree.FindAll(text)
+		if want == "" {
+			assert.Equal(t, len(matches), 0)
+			continue
+		}
+		assert.Equal(t, len(matches), 1)
+		assert.Equal(t, matches[0].Word, want)
+	}
+	counts := map[string]uint64{}
+	for _, stats := range tree.DebugInfos() {
+		counts[stats.Word] = stats.HitCount
+	}
+	assert.Equal(t, counts, map[string]uint64{"丑八": 4, "臭虫": 2})
+
+	tree.RemoveWords("丑八")
+	isHit, _ = tree.Detect("chou八", 1)
+	assert.Equal(t, isHit, true)
+	tree.RemoveWords("丑八")
+	for _, text := range []string{"丑八", "chou八", "chouba"} {
+		isHit, _ = tree.Detect(text, 1)
+		assert.Equal(t, isHit, false)
+	}
+	isHit, _ = tree.Detect("chou虫", 1)
+	assert.Equal(t, isHit, true)
+}
+
+func TestSource(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddEntries(
+		Entry{Word: "重口"},
+		Entry{Word: "zhongkou", Source: "重口"},
+		Entry{Word: "chongkou", Source: "重口"},
+	)
+
+	sources := map[string]string{}
+	for _, stats := range tree.Clone().DebugInfos() {
+		sources[stats.Word] = stats.Source
+	}
+	assert.Equal(t, sources, map[string]string{"重口": "", "zhongkou": "重口", "chongkou": "重口"})
+}
+
+func TestHomophone(t *testing.T) {
+	readings := map[rune][]string{
+		'丑': {"chou"}, '瞅': {"chou"}, '八': {"ba"}, '巴': {"ba"}, '怪': {"guai"},
+		'傻': {"sha"}, '逼': {"bi"}, '比': {"bi"}, '鼻': {"bi"}, '兽': {"shou"}, '手': {"shou"},
+	}
+	tree := NewTrieTree()
+	tree.WithHomophone(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.AddWords("丑八怪", "傻逼")
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"你个瞅八怪":  {true, "瞅八怪"},
+		"丑巴怪":    {true, "丑巴怪"},
+		"瞅-巴-怪":  {true, "瞅巴怪"},
+		"傻比":     {true, "傻比"},
+		"丑八":     {false, ""},
+		"你好":     {false, ""},
+		"丑八guai": {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	isHit, newText := tree.Replace("大傻鼻子", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "大**子")
+
+	// 与混合匹配同时开启时，读音同样可以匹配拼音
+	tree = NewTrieTree()
+	tree.WithHomophone(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.WithPinyin(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.AddWords("丑八怪")
+	isHit, hitWords := tree.Detect("瞅ba怪", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords[0], "瞅ba怪")
+
+	clone := tree.Clone()
+	clone.AddWords("傻逼")
+	isHit, _ = clone.Detect("傻比", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = tree.Detect("傻比", 1)
+	assert.Equal(t, isHit, false)
+
+	// 之后新增的读音不影响白名单短语
+	tree = NewTrieTree()
+	tree.WithHomophone(func(ch rune) []string {
+		return readings[ch]
+	})
+	tree.AddWords("丑八怪")
+	tree.AddAllowWords("丑八怪兽")
+	tree.AddWords("手")
+	for text, hit := range map[string]bool{
+		"丑八怪兽": false,
+		"瞅八怪手": false,
+		"丑八怪":  true,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+}
+
+func TestComboWindow(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddEntries(
+		Entry{Word: "司马南|美国", ComboRunes: 5},
+		Entry{Word: "枪支|出售", ComboSentences: 1},
+		Entry{Word: "丑八怪|丑女"},
+	)
+
+	for text, hit := range map[string]bool{
+		"司马南去了美国":               true,
+		"美国人说司马南":               true,
+		"司马南今天在家里吃饭，后来去了美国":     false,
+		"出售枪支":                  true,
+		"我们出售玩具。枪支是违禁品":         false,
+		"枪支，出售":                 true,
+		"丑八怪很多字之后。。。还有丑女":       true,
+		"司马南今天在家里吃饭，后来去了美国，司马南": true,
+	} {
+		isHit, _ := tree.Detect(text, 1)
+		assert.Equal(t, isHit, hit)
+	}
+
+	// 只替换窗口内的片段
+	isHit, newText := tree.Replace("美国很远，司马南在美国，过了很久很久才回美国", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "**很远，***在**，过了很久很久才回美国")
+
+	// 全局窗口，词上的设置优先
+	tree = NewTrieTree()
+	tree.WithComboWindow(0, 1)
+	tree.AddEntries(
+		Entry{Word: "司马南|美国"},
+		Entry{Word: "枪支|出售", ComboRunes: 20},
+	)
+	isHit, _ = tree.Detect("司马南。美国", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = tree.Detect("枪支。出售", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = tree.Clone().Detect("司马南，美国", 1)
+	assert.Equal(t, isHit, true)
+}
+
+func TestOrderedCombo(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddWords("司马南>美国", "出售>枪支>子弹", "丑八怪|丑女", "<script>", "a>>b")
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"司马南去了美国":     {true, "司马南>美国"},
+		"美国人说司马南":     {false, ""},
+		"美国人说司马南去了美国": {true, "司马南>美国"},
+		"出售枪支和子弹":     {true, "出售>枪支>子弹"},
+		"出售子弹和枪支":     {false, ""},
+		"丑女说丑八怪":      {true, "丑八怪|丑女"},
+		// 存在空片段时按普通词处理
+		"<script>alert(1)</script>": {true, "script"},
+		"a > b":                     {true, "ab"},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	isHit, newText := tree.Replace("美国人说司马南去了美国", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "美国人说***去了**")
+
+	matches := tree.FindAll("司马南去了美国")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "司马南>美国")
+
+	// 有序与无序组合词需分别删除
+	tree.RemoveWords("司马南|美国")
+	isHit, _ = tree.Detect("司马南去了美国", 1)
+	assert.Equal(t, isHit, true)
+	tree.RemoveWords("司马南>美国")
+	isHit, _ = tree.Detect("司马南去了美国", 1)
+	assert.Equal(t, isHit, false)
+}
+
+func TestRule(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithStats()
+	tree.AddEntries(
+		Entry{Word: "(枪支|弹药) AND 出售 AND NOT 新闻", Rule: true, Name: "违禁品交易"},
+		Entry{Word: "!(司马南 OR 美国) & 丑八怪", Rule: true},
+	)
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"出售枪支":      {true, "违禁品交易"},
+		"弹药，低价出售":   {true, "违禁品交易"},
+		"新闻：有人出售枪支": {false, ""},
+		"出售玩具":      {false, ""},
+		"枪支":        {false, ""},
+		"你个丑八怪":     {true, "!(司马南 OR 美国) & 丑八怪"},
+		"美国的丑八怪":    {false, ""},
+		"司马南":       {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	// 只替换正向的词
+	isHit, newText := tree.Replace("出售枪支和弹药，不是玩具", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "****和**，不是玩具")
+
+	matches := tree.FindAll("弹药，低价出售")
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "违禁品交易")
+	assert.Equal(t, matches[0].Text, "弹药")
+	assert.Equal(t, len(matches[0].Combo), 1)
+	assert.Equal(t, matches[0].Combo[0].Word, "出售")
+
+	stats := map[string]uint64{}
+	for _, s := range tree.DebugInfos() {
+		stats[s.Word] = s.HitCount
+	}
+	assert.Equal(t, stats["违禁品交易"], uint64(4))
+
+	clone := tree.Clone()
+	clone.RemoveWords("(枪支|弹药) AND 出售 AND NOT 新闻")
+	isHit, _ = clone.Detect("出售枪支", 1)
+	assert.Equal(t, isHit, false)
+	isHit, _ = tree.Detect("出售枪支", 1)
+	assert.Equal(t, isHit, true)
+
+	// 归一化结果相同的词共用同一个节点，按归一化后的词查找命中
+	tree = NewTrieTree()
+	tree.WithCaseFold()
+	tree.AddEntries(
+		Entry{Word: "Gun AND sell", Rule: true},
+		Entry{Word: "gun AND buy", Rule: true},
+	)
+	tree.AddWords("司马南|Gun", "方舟子|gun")
+	for text, want := range map[string]string{
+		"gun sell": "Gun AND sell",
+		"GUN buy":  "gun AND buy",
+		"司马南 gun":  "司马南|Gun",
+		"方舟子 Gun":  "方舟子|gun",
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, hitWords[0], want)
+	}
+
+	for expr, valid := range map[string]bool{
+		"A AND (B OR C)": true,
+		"A AND":          false,
+		"(A OR B":        false,
+		"A B":            false,
+		"NOT A":          true,
+	} {
+		err := Entry{Word: expr, Rule: true}.Validate()
+		assert.Equal(t, err == nil, valid)
+	}
+}
+
+func TestAllowWords(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithStats()
+	tree.AddWords("sb", "习近平", "司马南|美国")
+	tree.AddEntries(Entry{Word: "sbt", Allow: true})
+	tree.AddAllowWords("习近平同志", "美国队长")
+
+	for text, want := range map[string]struct {
+		isHit bool
+		word  string
+	}{
+		"sbt 数据":   {false, ""},
+		"你个sb":     {true, "sb"},
+		"sb用sbt":   {true, "sb"},
+		"习近平同志":    {false, ""},
+		"习近平":      {true, "习近平"},
+		"司马南看美国队长": {false, ""},
+		"司马南看美国":   {true, "司马南|美国"},
+		"s-b-t":    {false, ""},
+	} {
+		isHit, hitWords := tree.Detect(text, 1)
+		assert.Equal(t, isHit, want.isHit)
+		if isHit {
+			assert.Equal(t, hitWords[0], want.word)
+		}
+	}
+
+	var suppressed []*Match
+	isHit, newText := tree.Replace("sbt 和 sb", '*', WithSuppressed(&suppressed))
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "sbt 和 **")
+	assert.Equal(t, len(suppressed), 1)
+	assert.Equal(t, suppressed[0].Word, "sb")
+	assert.Equal(t, suppressed[0].Allow, "sbt")
+	assert.Equal(t, suppressed[0].RuneStart, 0)
+
+	stats := map[string]*Stats{}
+	for _, s := range tree.DebugInfos() {
+		stats[s.Word] = s
+	}
+	assert.Equal(t, stats["sbt"].Allow, true)
+	assert.Equal(t, stats["sbt"].HitCount, uint64(4))
+	assert.Equal(t, stats["sb"].SuppressedCount, uint64(4))
+	assert.Equal(t, stats["习近平"].SuppressedCount, uint64(1))
+
+	clone := tree.Clone()
+	clone.RemoveAllowWords("sbt")
+	isHit, _ = clone.Detect("sbt", 1)
+	assert.Equal(t, isHit, true)
+	isHit, _ = tree.Detect("sbt", 1)
+	assert.Equal(t, isHit, false)
+}
+
+func TestCategory(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithCategoryMaxGap(map[string]int{"abuse": 1})
+	tree.AddEntries(
+		Entry{Word: "司马南|美国", Category: "politics", Severity: 3},
+		Entry{Word: "丑八怪", Category: "abuse", Severity: 2},
+		Entry{Word: "笨蛋", Category: "mild", Severity: 1},
+		Entry{Word: "1[3-9][0-9]{9}", Regexp: true, Category: "contact", Severity: 2},
+	)
+
+	matches := tree.FindAll("司马南说美国人是笨蛋")
+	assert.Equal(t, len(matches), 2)
+	assert.Equal(t, matches[0].Category, "politics")
+	assert.Equal(t, matches[0].Severity, 3)
+	assert.Equal(t, matches[1].Category, "mild")
+
+	// 分类的间隔字符数
+	isHit, hitWords := tree.Detect("丑了八怪", 1)
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords[0], "丑了八怪")
+	isHit, _ = tree.Detect("丑了个八怪", 1)
+	assert.Equal(t, isHit, false)
+
+	text := "司马南说美国人是丑八怪和笨蛋，电话13800138000"
+	isHit, hitWords = tree.Detect(text, 1, WithCategories("abuse", "mild"))
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWords[0], "丑八怪")
+
+	isHit, newText := tree.Replace(text, '*', WithMinSeverity(2))
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "***说**人是***和笨蛋，电话***********")
+
+	isHit, _ = tree.Detect("笨蛋", 1, WithMinSeverity(2))
+	assert.Equal(t, isHit, false)
+
+	// 默认筛选条件
+	tree.WithSeverityFilter([]string{"politics"}, 0)
+	isHit, newText = tree.Clone().Replace(text, '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "***说**人是丑八怪和笨蛋，电话13800138000")
+
+	stats := map[string]*Stats{}
+	for _, s := range tree.DebugInfos() {
+		stats[s.Word] = s
+	}
+	assert.Equal(t, stats["丑八怪"].Category, "abuse")
+	assert.Equal(t, stats["1[3-9][0-9]{9}"].Severity, 2)
+}
+
+func TestReplacement(t *testing.T) {
+	tree := NewTrieTree()
+	tree.AddEntries(
+		Entry{Word: "笨蛋", Replacement: "**"},
+		Entry{Word: "他妈的", Replacement: "哎呀"},
+		Entry{Word: "司马南|美国", Replacement: "[已屏蔽]"},
+		Entry{Word: "丑八怪"},
+	)
+
+	for text, want := range map[string]string{
+		"你这个笨蛋":     "你这个**",
+		"大笨笨蛋蛋":     "大笨**蛋",
+		"他-妈-的，丑八怪": "哎呀，***",
+		"他 妈 的!":    "哎呀!",
+		"司马南说美国":    "[已屏蔽]说**",
+		"丑 八 怪":     "* * *",
+		"他妈的笨蛋":     "哎呀**",
+	} {
+		isHit, newText := tree.Replace(text, '*')
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, newText, want)
+	}
+
+	// 重叠的命中只替换一次
+	isHit, newText := tree.Clone().Replace("他妈的他妈的", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "哎呀哎呀")
+}
+
+func TestMasker(t *testing.T) {
+	text := "你个丑-八-怪，笨蛋"
+	for _, c := range []struct {
+		masker Masker
+		want   string
+	}{
+		{RuneMasker('#'), "你个#-#-#，##"},
+		{SingleMasker('*'), "你个*--，*"},
+		{KeepEndsMasker('*'), "你个丑-*-怪，**"},
+		{DeleteMasker(), "你个--，"},
+		{FixedMasker('*', 3), "你个***--，***"},
+		{MaskerFunc(func(match *Match) string {
+			return "<" + match.Word + ">"
+		}), "你个<丑八怪>--，<笨蛋>"},
+	} {
+		tree := NewTrieTree()
+		tree.WithMasker(c.masker)
+		tree.AddWords("丑八怪", "笨蛋")
+		isHit, newText := tree.Replace(text, '*')
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, newText, c.want)
+	}
+
+	// 相互重叠的命中合并后只替换一次
+	for _, c := range []struct {
+		masker Masker
+		want   string
+	}{
+		{RuneMasker('*'), "你***啊"},
+		{SingleMasker('*'), "你*啊"},
+		{KeepEndsMasker('*'), "你丑*怪啊"},
+		{FixedMasker('*', 5), "你*****啊"},
+		{MaskerFunc(func(match *Match) string {
+			return "<" + match.Word + ">"
+		}), "你<丑八怪>啊"},
+	} {
+		tree := NewTrieTree()
+		tree.WithMasker(c.masker)
+		tree.AddWords("丑八", "丑八怪", "八怪")
+		isHit, newText := tree.Replace("你丑八怪啊", '*')
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, newText, c.want)
+	}
+
+	// 替换文本优先于替换策略，组合词的其他片段按替换策略处理
+	tree := NewTrieTree()
+	tree.WithMasker(KeepEndsMasker('*'))
+	tree.AddEntries(
+		Entry{Word: "他妈的", Replacement: "哎呀"},
+		Entry{Word: "司马南|美国"},
+	)
+	isHit, newText := tree.Clone().Replace("他妈的，司马南说美国", '*')
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "哎呀，司*南说**")
+}
+
+func TestScanner(t *testing.T) {
+	tree := NewTrieTree()
+	tree.WithWholeWord()
+	tree.AddWords([]string{
+		"垃圾", "bad", "badass", "司马南|美国", "方舟子>死了", "丑八", "丑八怪",
+	}...)
+	tree.AddEntries(Entry{Word: "出售 AND 枪支", Rule: true})
+
+	text := "你是垃--圾, so b-a-d，badass 和 badly，司马南在美国。xxxxxxxxxx丑八怪yyyyyyyyyy" +
+		strings.Repeat("，", 50) + "垃\n\n圾。司马南说" + strings.Repeat("好", 20) + "美国，方舟子" + strings.Repeat("好", 30) +
+		"死了，出售" + strings.Repeat("好", 40) + "枪支，垃圾"
+	for _, policy := range []MatchPolicy{MatchAll, MatchLeftmostLongest, MatchLeftmostShortest} {
+		want := tree.FindAll(text, WithPolicy(policy))
+		for _, size := range [][2]int{{1, 128}, {2, 128}, {3, 128}, {4, 128}, {8, 128}, {8, 1024}, {4096, 1024}} {
+			scanner := tree.NewScanner(strings.NewReader(text), WithPolicy(policy))
+			scanner.Buffer(size[0], size[1])
+			var matches []*Match
+			for scanner.Scan() {
+				matches = append(matches, scanner.Match())
+			}
+			assert.Equal(t, scanner.Err(), nil)
+			assert.Equal(t, matches, want)
+		}
+	}
+
+	// 组合词的其他片段在后续的块中出现
+	text = "司马南说" + strings.Repeat("好", 5000) + "美国"
+	assert.Equal(t, len(tree.FindAll(text)), 1)
+	scanner := tree.NewScanner(strings.NewReader(text))
+	assert.Equal(t, scanner.Scan(), true)
+	assert.Equal(t, scanner.Match().Word, "司马南|美国")
+	assert.Equal(t, scanner.Match().Combo[0].RuneStart, 5004)
+	assert.Equal(t, scanner.Scan(), false)
+
+	// 超出保留范围的组合词不再等待，之后的命中照常返回
+	scanner = tree.NewScanner(strings.NewReader("司马南说" + strings.Repeat("好", 100) + "美国，垃圾"))
+	scanner.Buffer(8, 16)
+	assert.Equal(t, scanner.Scan(), true)
+	assert.Equal(t, scanner.Match().Word, "垃圾")
+	assert.Equal(t, scanner.Scan(), false)
+
+	// 偏移量为在整个输入中的偏移
+	scanner = tree.NewScanner(strings.NewReader(strings.Repeat("好", 10000) + "垃-圾"))
+	scanner.Buffer(16, 4)
+	assert.Equal(t, scanner.Scan(), true)
+	assert.Equal(t, *scanner.Match(), Match{
+		Word: "垃圾", Text: "垃-圾", Start: 30000, End: 30007, RuneStart: 10000, RuneEnd: 10003,
+	})
+	assert.Equal(t, scanner.Scan(), false)
+
+	// 保留的字符数少于最长的词时按最长的词保留
+	text = strings.Repeat("垃圾，", 200)
+	for _, size := range [][2]int{{4, 1}, {1, 0}, {3, 2}} {
+		scanner = tree.NewScanner(strings.NewReader(text))
+		scanner.Buffer(size[0], size[1])
+		count := 0
+		for scanner.Scan() {
+			count++
+		}
+		assert.Equal(t, count, 200)
+	}
+
+	// 读取出错时停止查找
+	scanner = tree.NewScanner(iotest.ErrReader(io.ErrUnexpectedEOF))
+	assert.Equal(t, scanner.Scan(), false)
+	assert.Equal(t, scanner.Err(), io.ErrUnexpectedEOF)
+}
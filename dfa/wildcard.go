@@ -0,0 +1,114 @@
+package dfa
+
+// defaultWildcardMax 未指定范围的 * 最多匹配的字符数
+const defaultWildcardMax = 3
+
+// parseWildcard 解析通配符：? 匹配任意单个字符，*{m,n} 匹配 m 到 n 个任意字符（* 等同于 *{0,3}），
+// [票漂] 匹配其中任一字符。开头与结尾的 * 没有意义会被忽略，无法解析的符号按特殊字符处理
+func (tree *TrieTree) parseWildcard(word string) []patternStep {
+	var (
+		runes = []rune(word)
+		steps []patternStep
+		gap   int
+	)
+	push := func(step patternStep) {
+		if len(steps) > 0 {
+			step.gap = gap
+		}
+		gap = 0
+		steps = append(steps, step)
+	}
+
+	for i := 0; i < len(runes); i++ {
+		switch ch := runes[i]; ch {
+		case '?':
+			push(patternStep{alternatives: [][]rune{{anyRune}}})
+		case '*':
+			lower, upper, size := parseRepeat(runes[i+1:])
+			i += size
+			for j := 0; j < lower; j++ {
+				push(patternStep{alternatives: [][]rune{{anyRune}}})
+			}
+			gap += upper - lower
+		case '[':
+			end := indexRune(runes[i+1:], ']')
+			if end < 0 {
+				continue
+			}
+			var alternatives [][]rune
+			for _, member := range runes[i+1 : i+1+end] {
+				if alternative := tree.normalizeChar(member); len(alternative) > 0 {
+					alternatives = append(alternatives, alternative)
+				}
+			}
+			i += end + 1
+			if len(alternatives) > 0 {
+				push(patternStep{alternatives: alternatives})
+			}
+		default:
+			for _, c := range tree.normalizeChar(ch) {
+				push(patternStep{alternatives: [][]rune{{c}}})
+			}
+		}
+	}
+	return steps
+}
+
+// parseRepeat 解析 * 之后的 {m,n} 或 {n}，返回范围及消耗的字符数
+func parseRepeat(runes []rune) (lower, upper, size int) {
+	if len(runes) == 0 || runes[0] != '{' {
+		return 0, defaultWildcardMax, 0
+	}
+	end := indexRune(runes, '}')
+	if end < 0 {
+		return 0, defaultWildcardMax, 0
+	}
+
+	var (
+		bounds = [2]int{}
+		n      = 0
+		digits = 0
+	)
+	for _, ch := range runes[1:end] {
+		switch {
+		case ch >= '0' && ch <= '9':
+			bounds[n] = bounds[n]*10 + int(ch-'0')
+			digits++
+		case ch == ',' && n == 0 && digits > 0:
+			n, digits = 1, 0
+		default:
+			return 0, defaultWildcardMax, 0
+		}
+	}
+	if digits == 0 {
+		return 0, defaultWildcardMax, 0
+	}
+	if n == 0 {
+		bounds[1] = bounds[0]
+	}
+	if bounds[1] < bounds[0] {
+		return 0, defaultWildcardMax, 0
+	}
+	return bounds[0], bounds[1], end + 1
+}
+
+// normalizeChar 单个字符归一化后的非特殊字符
+func (tree *TrieTree) normalizeChar(ch rune) []rune {
+	var chars []rune
+	for _, u := range tree.normalize([]rune{ch}) {
+		if tree.isFilterChar(u.ch) {
+			continue
+		}
+		chars = append(chars, u.ch)
+	}
+	return chars
+}
+
+func indexRune(runes []rune, ch rune) int {
+	for i, c := range runes {
+		if c == ch {
+			return i
+		}
+	}
+	return -1
+}
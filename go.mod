@@ -3,10 +3,14 @@ module github.com/mingolm/sensitive-words
 go 1.18
 
 require (
-	github.com/go-playground/assert/v2 v2.0.1 // indirect
-	github.com/mozillazg/go-pinyin v0.19.0 // indirect
-	go.uber.org/atomic v1.7.0 // indirect
+	github.com/go-playground/assert/v2 v2.0.1
+	github.com/mozillazg/go-pinyin v0.19.0
+	go.uber.org/atomic v1.7.0
+	go.uber.org/zap v1.22.0
+	golang.org/x/text v0.14.0
+)
+
+require (
 	go.uber.org/multierr v1.6.0 // indirect
-	go.uber.org/zap v1.22.0 // indirect
 	golang.org/x/sync v0.0.0-20220819030929-7fc1605a5dde // indirect
 )
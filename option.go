@@ -3,20 +3,52 @@ package sensitive_words
 import (
 	"time"
 
+	"github.com/mingolm/sensitive-words/dfa"
 	"go.uber.org/zap"
 )
 
 type options struct {
 	// 掩码字符，默认使用 *
 	maskWord rune
+	// 替换策略，为空时使用 maskWord 逐字替换
+	masker dfa.Masker
 	// 查找/替换模式，默认开启拼音
 	mode Mode
 	// 过滤特殊字符，默认过滤除中英文数字之外的所有字符
 	filterChars []rune
+	// 匹配策略，默认返回所有命中
+	matchPolicy dfa.MatchPolicy
+	// 形近字符映射表，为空时使用 dfa.DefaultConfusables
+	confusables map[rune]rune
+	// 相邻字符之间允许出现的最多任意字符数，仅对不少于 maxGapMinLength 个字符的词生效
+	maxGap          int
+	maxGapMinLength int
+	// 按分类设置的间隔字符数
+	categoryGaps map[string]int
+	// 默认只返回这些分类及不低于 minSeverity 的命中
+	categories  []string
+	minSeverity int
+	// ModeFuzzy 下按词长分档允许的编辑次数，仅对拉丁词生效，默认不少于 4 个字符的词允许 1 次
+	fuzzyTiers []dfa.FuzzyTier
+	// ModePinyinInitials 下首个片段的首字母不少于 pinyinInitialsMinLength 个才生成，默认 3
+	pinyinInitialsMinLength int
+	// 多音字展开拼音时每个词最多生成的变体数，默认 8
+	pinyinMaxVariants int
+	// ModeHomophone 下是否区分声调，默认忽略声调
+	homophoneTone bool
+	// 组合词各片段之间的距离限制，为 0 表示不限制
+	comboRunes     int
+	comboSentences int
 	// 定时触发回调方法间隔
 	rebuildWordsInterval time.Duration
 	// 创建敏感词回调方法
 	buildWordsCall BuildWordsFn
+	// 创建带匹配规则的敏感词回调方法
+	buildEntriesCall BuildEntriesFn
+	// 创建白名单短语回调方法
+	buildAllowWordsCall BuildWordsFn
+	// 拉丁、西里尔字母及数字组成的词要求单词边界
+	wholeWord bool
 	// 日志
 	logger *zap.SugaredLogger
 }
@@ -29,6 +61,14 @@ func WithMaskWord(word rune) Option {
 	}
 }
 
+// WithMasker 设置 MatchReplace 的替换策略，内置 dfa.RuneMasker、dfa.SingleMasker、dfa.KeepEndsMasker、
+// dfa.DeleteMasker、dfa.FixedMasker，也可以通过 dfa.MaskerFunc 自定义，特殊字符始终保持不变
+func WithMasker(masker dfa.Masker) Option {
+	return func(o *options) {
+		o.masker = masker
+	}
+}
+
 func WithMode(modes ...Mode) Option {
 	return func(o *options) {
 		for _, m := range modes {
@@ -43,6 +83,119 @@ func WithFilterChars(filterChars ...rune) Option {
 	}
 }
 
+// WithMatchPolicy 默认匹配策略，单次调用可通过 dfa.WithPolicy 覆盖
+func WithMatchPolicy(policy dfa.MatchPolicy) Option {
+	return func(o *options) {
+		o.matchPolicy = policy
+	}
+}
+
+// WithBuildEntries 追加带匹配规则的敏感词数据源，与 BuildWordsFn 的结果合并
+func WithBuildEntries(buildEntries BuildEntriesFn) Option {
+	return func(o *options) {
+		o.buildEntriesCall = buildEntries
+	}
+}
+
+// WithWholeWord 拉丁、西里尔字母及数字组成的词要求单词边界，如 ass 不再命中 class
+func WithWholeWord() Option {
+	return func(o *options) {
+		o.wholeWord = true
+	}
+}
+
+// WithConfusables 自定义 ModeConfusables 使用的形近字符映射表
+func WithConfusables(table map[rune]rune) Option {
+	return func(o *options) {
+		o.confusables = table
+	}
+}
+
+// WithMaxGap 不少于 minLength 个字符的词允许间隔最多 gap 个任意字符，如 丑八怪 可以命中 丑了八个怪，
+// 单个词可以通过 dfa.Entry.MaxGap 单独设置，小于 0 时不允许间隔。
+// 允许间隔的词不再经过自动机匹配，耗时约为普通词的 35 倍，词库较大时应调高 minLength 或按分类开启
+func WithMaxGap(gap, minLength int) Option {
+	return func(o *options) {
+		o.maxGap = gap
+		o.maxGapMinLength = minLength
+	}
+}
+
+// WithFuzzy ModeFuzzy 下不少于 minLength 个字符的拉丁词、拼音词允许最多 distance 次编辑，如 fuck 可以命中 fcuk
+func WithFuzzy(distance, minLength int) Option {
+	return WithFuzzyTiers(dfa.FuzzyTier{MinLength: minLength, Distance: distance})
+}
+
+// WithFuzzyTiers ModeFuzzy 下按词长分档设置允许的编辑次数，如 {4, 1}、{8, 2} 表示 4 至 7 个字符的词允许 1 次、8 个及以上允许 2 次
+func WithFuzzyTiers(tiers ...dfa.FuzzyTier) Option {
+	return func(o *options) {
+		o.fuzzyTiers = tiers
+	}
+}
+
+// WithPinyinInitialsMinLength ModePinyinInitials 下首字母不少于 minLength 个的词才生成首字母变体，如设为 2 时 傻逼 生成 sb
+func WithPinyinInitialsMinLength(minLength int) Option {
+	return func(o *options) {
+		o.pinyinInitialsMinLength = minLength
+	}
+}
+
+// WithPinyinMaxVariants 多音字展开拼音时每个词最多生成 maxVariants 个变体，如 重 同时生成 zhong 与 chong
+func WithPinyinMaxVariants(maxVariants int) Option {
+	return func(o *options) {
+		o.pinyinMaxVariants = maxVariants
+	}
+}
+
+// WithHomophoneTone ModeHomophone 下要求声调一致，如 丑八怪 不再命中 抽八怪
+func WithHomophoneTone() Option {
+	return func(o *options) {
+		o.homophoneTone = true
+	}
+}
+
+// WithComboWindow 组合词其他片段与首个片段之间最多间隔 runes 个字符、最多跨越 sentences 个句子，
+// 窗口外的片段不参与判断也不会被替换，单个词可以通过 dfa.Entry.ComboRunes、dfa.Entry.ComboSentences 单独设置
+func WithComboWindow(runes, sentences int) Option {
+	return func(o *options) {
+		o.comboRunes = runes
+		o.comboSentences = sentences
+	}
+}
+
+// WithBuildAllowWords 白名单短语，被短语完整覆盖的命中不再返回，如 sbt 中的 sb，
+// 被抑制的命中可以通过 dfa.WithSuppressed 获取，抑制次数在 DebugInfos 中返回
+func WithBuildAllowWords(buildAllowWords BuildWordsFn) Option {
+	return func(o *options) {
+		o.buildAllowWordsCall = buildAllowWords
+	}
+}
+
+// WithCategoryMaxGap 分类为 category 的词允许间隔最多 gap 个任意字符，优先于 WithMaxGap，
+// 耗时与 WithMaxGap 相同，约为普通词的 35 倍，gap 小于 0 时该分类的词不允许间隔
+func WithCategoryMaxGap(category string, gap int) Option {
+	return func(o *options) {
+		if o.categoryGaps == nil {
+			o.categoryGaps = map[string]int{}
+		}
+		o.categoryGaps[category] = gap
+	}
+}
+
+// WithCategories 默认只返回指定分类的命中，单次调用可以通过 dfa.WithCategories 覆盖
+func WithCategories(categories ...string) Option {
+	return func(o *options) {
+		o.categories = categories
+	}
+}
+
+// WithMinSeverity 默认只返回严重程度不低于 severity 的命中，单次调用可以通过 dfa.WithMinSeverity 覆盖
+func WithMinSeverity(severity int) Option {
+	return func(o *options) {
+		o.minSeverity = severity
+	}
+}
+
 func WithRebuildWordsInterval(interval time.Duration) Option {
 	return func(o *options) {
 		o.rebuildWordsInterval = interval
@@ -2,7 +2,9 @@ package sensitive_words
 
 import (
 	"context"
+	"io"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,11 +15,19 @@ import (
 
 type SensitiveWorder interface {
 	// Hit 判断是否命中敏感词，且返回命中的敏感词
-	Hit(ctx context.Context, text string) (isHit bool, hitWord string, err error)
+	Hit(ctx context.Context, text string, opts ...dfa.ScanOption) (isHit bool, hitWord string, err error)
 	// HitMust 严格模式，最少命中几个敏感词
-	HitMust(ctx context.Context, text string, times int) (isHit bool, hitWords []string, err error)
+	HitMust(ctx context.Context, text string, times int, opts ...dfa.ScanOption) (isHit bool, hitWords []string, err error)
+	// FindAll 返回所有命中的位置信息
+	FindAll(ctx context.Context, text string, opts ...dfa.ScanOption) (matches []*dfa.Match, err error)
+	// Scan 流式读取 reader 并逐个回调命中，适用于无法一次性读入内存的大文本，fn 返回错误或 ctx 取消时停止读取
+	Scan(ctx context.Context, reader io.Reader, fn func(match *dfa.Match) error, opts ...dfa.ScanOption) error
 	// MatchReplace 敏感词替换
-	MatchReplace(ctx context.Context, text string) (isHit bool, lastText string, err error)
+	MatchReplace(ctx context.Context, text string, opts ...dfa.ScanOption) (isHit bool, lastText string, err error)
+	// AddWords 增量新增敏感词，无需重建整个词库
+	AddWords(ctx context.Context, words ...string) error
+	// RemoveWords 增量删除敏感词
+	RemoveWords(ctx context.Context, words ...string) error
 	// DebugInfos 输出当前所有敏感词
 	DebugInfos(ctx context.Context) (results []*dfa.Stats)
 }
@@ -26,10 +36,13 @@ var _ SensitiveWorder = (*sensitiveWord)(nil)
 
 func New(buildWords BuildWordsFn, opts ...Option) SensitiveWorder {
 	o := options{
-		maskWord:       '*',
-		buildWordsCall: buildWords,
-		mode:           ModePinyin,
-		logger:         zap.S().Named("sensitive"),
+		maskWord:                '*',
+		buildWordsCall:          buildWords,
+		mode:                    ModePinyin,
+		fuzzyTiers:              []dfa.FuzzyTier{{MinLength: 4, Distance: 1}},
+		pinyinInitialsMinLength: 3,
+		pinyinMaxVariants:       8,
+		logger:                  zap.S().Named("sensitive"),
 	}
 	for _, fn := range opts {
 		fn(&o)
@@ -71,68 +84,329 @@ func New(buildWords BuildWordsFn, opts ...Option) SensitiveWorder {
 type sensitiveWord struct {
 	options
 	trieTree atomic.Value
+	// 写锁，保证重建与增量修改串行执行
+	mu sync.Mutex
+	// 当前词库中的原始敏感词
+	words map[string]struct{}
 }
 
 func (st *sensitiveWord) buildWords(ctx context.Context) error {
 	st.logger.Debugw("rebuild words",
 		"start_time", time.Now().Format("2006-01-02 15:04:05"),
 	)
-	words, err := st.buildWordsCall(ctx)
-	if err != nil {
-		return err
+	var entries []dfa.Entry
+	if st.buildWordsCall != nil {
+		words, err := st.buildWordsCall(ctx)
+		if err != nil {
+			return err
+		}
+		for _, word := range words {
+			entries = append(entries, dfa.Entry{Word: word})
+		}
+	}
+	if st.buildEntriesCall != nil {
+		richEntries, err := st.buildEntriesCall(ctx)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, richEntries...)
+	}
+	if st.buildAllowWordsCall != nil {
+		allowWords, err := st.buildAllowWordsCall(ctx)
+		if err != nil {
+			return err
+		}
+		for _, word := range allowWords {
+			entries = append(entries, dfa.Entry{Word: word, Allow: true})
+		}
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var (
+		wordMap       = make(map[string]struct{}, len(entries))
+		allowMap      = map[string]struct{}{}
+		uniqueEntries = make([]dfa.Entry, 0, len(entries))
+	)
+	for _, entry := range entries {
+		// 白名单短语可以与敏感词相同，单独去重
+		seen := wordMap
+		if entry.Allow {
+			seen = allowMap
+		}
+		if _, ok := seen[entry.Word]; ok {
+			continue
+		}
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+		seen[entry.Word] = struct{}{}
+		uniqueEntries = append(uniqueEntries, entry)
 	}
 
 	tree := dfa.NewTrieTree()
 	tree.WithFilterChars(st.filterChars)
+	tree.WithMatchPolicy(st.matchPolicy)
+	tree.WithMaxGap(st.maxGap, st.maxGapMinLength)
+	tree.WithCategoryMaxGap(st.categoryGaps)
+	tree.WithSeverityFilter(st.categories, st.minSeverity)
+	tree.WithMasker(st.masker)
+	tree.WithComboWindow(st.comboRunes, st.comboSentences)
+	if st.wholeWord {
+		tree.WithWholeWord()
+	}
+	_ = st.mode.Range(func(value Mode) error {
+		switch value {
+		case ModeStats: // 开启命中敏感词统计
+			tree.WithStats()
+		case ModeCaseFold: // 开启大小写折叠
+			tree.WithCaseFold()
+		case ModeNFKC: // 开启 NFKC 归一化
+			tree.WithNFKC()
+		case ModeTraditional: // 开启繁简体等价匹配
+			tree.WithTraditional()
+		case ModeConfusables: // 开启形近字符折叠
+			tree.WithConfusables(st.confusables)
+		case ModeWildcard: // 开启通配符解析
+			tree.WithWildcard()
+		case ModeFuzzy: // 开启模糊匹配
+			tree.WithFuzzyTiers(st.fuzzyTiers...)
+		case ModeHomophone: // 开启同音字匹配
+			args := pinyin.NewArgs()
+			if st.homophoneTone {
+				args.Style = pinyin.Tone
+			}
+			tree.WithHomophone(func(ch rune) []string {
+				for _, readings := range pinyin.Pinyin(string(ch), args) {
+					return readings
+				}
+				return nil
+			})
+		case ModePinyinMixed: // 开启汉字与拼音混合匹配
+			args := pinyin.NewArgs()
+			args.Heteronym = true
+			tree.WithPinyin(func(ch rune) []string {
+				for _, readings := range pinyin.Pinyin(string(ch), args) {
+					return uniqueStrings(readings)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
 
-	if err = st.mode.Range(func(value Mode) error {
+	tree.AddEntries(st.expandEntries(uniqueEntries)...)
+	st.words = wordMap
+	st.trieTree.Store(tree)
+	st.logger.Debugw("rebuild words success",
+		"end_time", time.Now().Format("2006-01-02 15:04:05"),
+	)
+
+	return nil
+}
+
+// expandEntries 按模式扩展敏感词，扩展出的词沿用原词的匹配规则
+func (st *sensitiveWord) expandEntries(entries []dfa.Entry) []dfa.Entry {
+	expanded := append([]dfa.Entry(nil), entries...)
+	_ = st.mode.Range(func(value Mode) error {
 		switch value {
 		case ModePinyin: // 开启拼音模式
-			for _, word := range words {
-				if !pinyinWordReg.MatchString(word) {
+			for _, entry := range entries {
+				if !isPinyinEntry(entry) {
 					continue
 				}
-				var pinyinWords []string
-				for _, segWord := range strings.Split(word, "|") {
-					pinyinWords = append(pinyinWords, strings.Join(pinyin.LazyConvert(segWord, nil), ""))
+				// 混合匹配已经覆盖普通词的全拼，组合词的其他片段只按原词匹配，仍需生成
+				if st.mode.Contain(ModePinyinMixed) && !strings.Contains(entry.Word, dfa.ComboSeparator(entry.Word)) {
+					continue
 				}
-				words = append(words, strings.Join(pinyinWords, "|"))
+				expanded = append(expanded, st.pinyinEntries(entry, pinyin.Normal)...)
 			}
-		case ModeStats: // 开启命中敏感词统计
-			tree.WithStats()
+		case ModePinyinInitials: // 开启拼音首字母模式
+			for _, entry := range entries {
+				if !isPinyinEntry(entry) {
+					continue
+				}
+				// 首字母过短误报太多，组合词的其他片段只在首个片段命中后才检查，不限制长度
+				if len(pinyin.LazyConvert(strings.Split(entry.Word, dfa.ComboSeparator(entry.Word))[0], nil)) < st.pinyinInitialsMinLength {
+					continue
+				}
+				expanded = append(expanded, st.pinyinEntries(entry, pinyin.FirstLetter)...)
+			}
+		}
+		return nil
+	})
+	return expanded
+}
+
+// isPinyinEntry 仅普通中文词及组合词生成拼音变体
+func isPinyinEntry(entry dfa.Entry) bool {
+	return !entry.Regexp && !entry.Rule && !entry.Allow && pinyinWordReg.MatchString(entry.Word)
+}
+
+// pinyinEntries 按多音字的所有读音生成拼音变体，默认读音排在最前，变体数不超过 pinyinMaxVariants，
+// 变体记录来源词，便于在 DebugInfos 中追溯
+func (st *sensitiveWord) pinyinEntries(entry dfa.Entry, style int) []dfa.Entry {
+	args := pinyin.NewArgs()
+	args.Style = style
+	args.Heteronym = true
+
+	var (
+		separator = dfa.ComboSeparator(entry.Word)
+		variants  = []string{""}
+	)
+	for i, segWord := range strings.Split(entry.Word, separator) {
+		if i > 0 {
+			for j := range variants {
+				variants[j] += separator
+			}
+		}
+		for _, readings := range pinyin.Pinyin(segWord, args) {
+			var next []string
+			for _, variant := range variants {
+				for _, reading := range uniqueStrings(readings) {
+					if len(next) >= st.pinyinMaxVariants {
+						break
+					}
+					next = append(next, variant+reading)
+				}
+			}
+			variants = next
+		}
+	}
+
+	source := entry.Word
+	if entry.Source != "" {
+		source = entry.Source
+	}
+	results := make([]dfa.Entry, 0, len(variants))
+	for _, variant := range variants {
+		entry.Word = variant
+		entry.Source = source
+		results = append(results, entry)
+	}
+	return results
+}
+
+func uniqueStrings(values []string) []string {
+	var (
+		seen    = make(map[string]struct{}, len(values))
+		results = make([]string, 0, len(values))
+	)
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		results = append(results, value)
+	}
+	return results
+}
+
+// AddWords 写时复制：在当前词库的副本上追加，完成后整体替换，读取方不会看到中间状态。
+// 定时重建时词库会被数据源的结果覆盖，需要持久化的修改应同步到数据源
+func (st *sensitiveWord) AddWords(ctx context.Context, words ...string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var added []dfa.Entry
+	for _, word := range words {
+		if _, ok := st.words[word]; ok {
+			continue
 		}
+		st.words[word] = struct{}{}
+		added = append(added, dfa.Entry{Word: word})
+	}
+	if len(added) == 0 {
 		return nil
-	}); err != nil {
-		return err
 	}
 
-	tree.AddWords(words...)
+	tree := st.trieTree.Load().(*dfa.TrieTree).CloneAdd(st.expandEntries(added)...)
 	st.trieTree.Store(tree)
-	st.logger.Debugw("rebuild words success",
-		"end_time", time.Now().Format("2006-01-02 15:04:05"),
+	st.logger.Debugw("add words success",
+		"words", words,
 	)
 
 	return nil
 }
 
-func (st *sensitiveWord) Hit(ctx context.Context, text string) (isHit bool, hitWord string, err error) {
+// RemoveWords 写时复制：在当前词库的副本上删除，完成后整体替换
+func (st *sensitiveWord) RemoveWords(ctx context.Context, words ...string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var removed []dfa.Entry
+	for _, word := range words {
+		if _, ok := st.words[word]; !ok {
+			continue
+		}
+		delete(st.words, word)
+		removed = append(removed, dfa.Entry{Word: word})
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	var removedWords []string
+	for _, entry := range st.expandEntries(removed) {
+		removedWords = append(removedWords, entry.Word)
+	}
+	tree := st.trieTree.Load().(*dfa.TrieTree).CloneRemove(removedWords...)
+	st.trieTree.Store(tree)
+	st.logger.Debugw("remove words success",
+		"words", words,
+	)
+
+	return nil
+}
+
+func (st *sensitiveWord) Hit(ctx context.Context, text string, opts ...dfa.ScanOption) (isHit bool, hitWord string, err error) {
 	tree := st.trieTree.Load().(*dfa.TrieTree)
-	isHit, hitWords := tree.Detect(text, 1)
+	isHit, hitWords := tree.Detect(text, 1, opts...)
 	if isHit {
 		return true, hitWords[0], nil
 	}
 	return false, "", nil
 }
 
-func (st *sensitiveWord) HitMust(ctx context.Context, text string, times int) (isHit bool, hitWords []string, err error) {
+func (st *sensitiveWord) HitMust(ctx context.Context, text string, times int, opts ...dfa.ScanOption) (isHit bool, hitWords []string, err error) {
 	tree := st.trieTree.Load().(*dfa.TrieTree)
-	isHit, hitWords = tree.Detect(text, times)
+	isHit, hitWords = tree.Detect(text, times, opts...)
 	return isHit, hitWords, nil
 }
 
-func (st *sensitiveWord) MatchReplace(ctx context.Context, text string) (isHit bool, lastText string, err error) {
+func (st *sensitiveWord) FindAll(ctx context.Context, text string, opts ...dfa.ScanOption) (matches []*dfa.Match, err error) {
+	tree := st.trieTree.Load().(*dfa.TrieTree)
+	return tree.FindAll(text, opts...), nil
+}
+
+func (st *sensitiveWord) Scan(ctx context.Context, reader io.Reader, fn func(match *dfa.Match) error, opts ...dfa.ScanOption) error {
+	tree := st.trieTree.Load().(*dfa.TrieTree)
+	scanner := tree.NewScanner(ctxReader{ctx: ctx, reader: reader}, opts...)
+	for scanner.Scan() {
+		if err := fn(scanner.Match()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ctxReader 每次读取前检查 ctx，长时间没有命中时也能及时停止读取
+type ctxReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+func (st *sensitiveWord) MatchReplace(ctx context.Context, text string, opts ...dfa.ScanOption) (isHit bool, lastText string, err error) {
 	tree := st.trieTree.Load().(*dfa.TrieTree)
-	isHit, lastText = tree.Replace(text, st.maskWord)
+	isHit, lastText = tree.Replace(text, st.maskWord, opts...)
 	return isHit, lastText, nil
 }
 
@@ -2,11 +2,15 @@ package sensitive_words
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-playground/assert/v2"
+	"github.com/mingolm/sensitive-words/dfa"
+	"go.uber.org/zap"
 )
 
 func TestHit(t *testing.T) {
@@ -158,3 +162,770 @@ func buildWordsCall(ctx context.Context) (words []string, err error) {
 		"罗永浩|直播|翻车",
 	}, nil
 }
+
+func TestAddRemoveWords(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin, ModeStats),
+		WithMaskWord('*'),
+	)
+	ctx := context.Background()
+
+	if err := st.AddWords(ctx, "笨蛋", "丑八怪"); err != nil {
+		t.Fatal(err)
+	}
+	for text, hit := range map[string]bool{
+		"你这个笨蛋":  true,
+		"bendan": true,
+		"丑八怪":    true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	if err := st.RemoveWords(ctx, "笨蛋", "丑八怪", "方舟子|死了"); err != nil {
+		t.Fatal(err)
+	}
+	for text, hit := range map[string]bool{
+		"你这个笨蛋":      false,
+		"bendan":     false,
+		"choubaguai": false,
+		"方舟子早就该死了":   false,
+		"丑东西":        true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin, ModeStats),
+	)
+	ctx := context.Background()
+
+	text := "你这个丑（）东西, chou-baguai"
+	matches, err := st.FindAll(ctx, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(matches), 2)
+	assert.Equal(t, matches[0].Word, "丑东西")
+	assert.Equal(t, matches[0].Text, "丑（）东西")
+	assert.Equal(t, text[matches[0].Start:matches[0].End], "丑（）东西")
+	assert.Equal(t, matches[1].Word, "choubaguai")
+	assert.Equal(t, string([]rune(text)[matches[1].RuneStart:matches[1].RuneEnd]), "chou-baguai")
+}
+
+func TestScan(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin),
+	)
+	ctx := context.Background()
+
+	text := strings.Repeat("正常的内容，", 2000) + "你这个丑（）东西, chou-baguai"
+	want, err := st.FindAll(ctx, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matches []*dfa.Match
+	err = st.Scan(ctx, strings.NewReader(text), func(match *dfa.Match) error {
+		matches = append(matches, match)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(matches), 2)
+	assert.Equal(t, matches, want)
+	assert.Equal(t, text[matches[0].Start:matches[0].End], "丑（）东西")
+
+	stop := errors.New("stop")
+	err = st.Scan(ctx, strings.NewReader(text), func(match *dfa.Match) error {
+		return stop
+	})
+	assert.Equal(t, err, stop)
+
+	// 没有命中时同样可以取消
+	cancelCtx, cancel := context.WithCancel(ctx)
+	reads := 0
+	reader := readerFunc(func(p []byte) (int, error) {
+		if reads++; reads == 3 {
+			cancel()
+		}
+		return copy(p, "正常的内容，"), nil
+	})
+	err = st.Scan(cancelCtx, reader, func(match *dfa.Match) error {
+		return nil
+	})
+	assert.Equal(t, err, context.Canceled)
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestMatchPolicy(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"丑八", "丑八怪"}, nil
+		},
+		WithMatchPolicy(dfa.MatchLeftmostLongest),
+	)
+	ctx := context.Background()
+
+	_, hitWord, err := st.Hit(ctx, "你这个丑八怪")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hitWord, "丑八怪")
+
+	_, hitWord, err = st.Hit(ctx, "你这个丑八怪", dfa.WithPolicy(dfa.MatchLeftmostShortest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hitWord, "丑八")
+}
+
+func TestWholeWord(t *testing.T) {
+	st := New(
+		nil,
+		WithBuildEntries(func(ctx context.Context) ([]dfa.Entry, error) {
+			return []dfa.Entry{
+				{Word: "ass", WholeWord: true},
+				{Word: "傻逼"},
+			}, nil
+		}),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"first class": false,
+		"kiss my ass": true,
+		"大傻逼":         true,
+		"shabi":       true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+}
+
+func TestCaseFold(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin, ModeCaseFold),
+	)
+	ctx := context.Background()
+
+	isHit, hitWord, err := st.Hit(ctx, "你这个ShaZi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWord, "ShaZi")
+
+	isHit, newText, err := st.MatchReplace(ctx, "你这个ShaZi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你这个*****")
+}
+
+func TestNFKC(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin, ModeCaseFold, ModeNFKC),
+	)
+	ctx := context.Background()
+
+	isHit, newText, err := st.MatchReplace(ctx, "你这个ＳＨＡＺＩ！")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你这个*****！")
+}
+
+func TestTraditional(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin, ModeTraditional),
+	)
+	ctx := context.Background()
+
+	isHit, newText, err := st.MatchReplace(ctx, "聽說司馬南在美國買房子")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "聽說***在**買房子")
+}
+
+func TestConfusables(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin, ModeConfusables, ModeCaseFold),
+	)
+	ctx := context.Background()
+
+	isHit, newText, err := st.MatchReplace(ctx, "你这个$h4z1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, newText, "你这个*****")
+}
+
+func TestMaxGap(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin),
+		WithMaxGap(2, 3),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"你这个丑了八个怪": true,
+		"傻了子":      false,
+		"丑了个东西":    true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+}
+
+func TestRegexp(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin|ModeStats),
+		WithBuildEntries(func(ctx context.Context) ([]dfa.Entry, error) {
+			return []dfa.Entry{
+				{Word: "[一二三四五六七八九零〇]{11}", Regexp: true},
+			}, nil
+		}),
+	)
+	ctx := context.Background()
+	isHit, hitWord, err := st.Hit(ctx, "加我一三八零零一三八零零零")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWord, "一三八零零一三八零零零")
+
+	isHit, hitWords, err := st.HitMust(ctx, "丑八怪加我一三八零零一三八零零零", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, len(hitWords), 2)
+
+	isHit, lastText, err := st.MatchReplace(ctx, "加我一三八零零一三八零零零")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "加我***********")
+
+	for _, stats := range st.DebugInfos(ctx) {
+		if stats.Word == "[一二三四五六七八九零〇]{11}" {
+			assert.Equal(t, stats.HitCount, uint64(3))
+		}
+	}
+
+	assert.NotEqual(t, (&sensitiveWord{options: options{
+		logger: zap.S(),
+		buildEntriesCall: func(ctx context.Context) ([]dfa.Entry, error) {
+			return []dfa.Entry{{Word: "(", Regexp: true}}, nil
+		},
+	}}).buildWords(ctx), nil)
+}
+
+func TestFuzzy(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyin|ModeFuzzy),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"choubaguia": true,
+		"chuobagaui": false,
+		"choubagua":  true,
+		"chou":       false,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	matches, err := st.FindAll(ctx, "你个choubaguia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(matches), 1)
+	assert.Equal(t, matches[0].Word, "choubaguai")
+	assert.Equal(t, matches[0].Distance, 1)
+
+	// 长词允许更多编辑
+	st = New(
+		buildWordsCall,
+		WithMode(ModePinyin|ModeFuzzy),
+		WithFuzzyTiers(dfa.FuzzyTier{MinLength: 4, Distance: 1}, dfa.FuzzyTier{MinLength: 8, Distance: 2}),
+	)
+	isHit, _, err := st.Hit(ctx, "chuobagaui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+}
+
+func TestPinyinInitials(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"丑八怪", "傻逼", "你妈死了", "司马南|美国"}, nil
+		},
+		WithMode(ModePinyinInitials),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"你个cbg":      true,
+		"CBG":        false,
+		"nmsl":       true,
+		"sb":         false,
+		"smn说mg":     true,
+		"smn":        false,
+		"choubaguai": true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	// 降低最小长度后短词也生成首字母
+	st = New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"傻逼"}, nil
+		},
+		WithMode(ModePinyinInitials),
+		WithPinyinInitialsMinLength(2),
+	)
+	isHit, _, err := st.Hit(ctx, "大sb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+
+	assert.Equal(t, st.RemoveWords(ctx, "傻逼"), nil)
+	isHit, _, err = st.Hit(ctx, "大sb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, false)
+}
+
+func TestPinyinMixed(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithMode(ModePinyinMixed),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"你个丑ba怪":     true,
+		"chou八guai":  true,
+		"choubaguai": true,
+		"丑八怪":        true,
+		"chou八":      false,
+		// 组合词的其他片段仍生成全拼
+		"simanan meiguo": true,
+		"司马南在美国":         true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, hitWords, err := st.HitMust(ctx, "choubaguai", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, false)
+	assert.Equal(t, hitWords, []string{"choubaguai"})
+}
+
+func TestPinyinHeteronym(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"重口", "银行卡"}, nil
+		},
+		WithMode(ModeStats),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"zhongkou":    true,
+		"chongkou":    true,
+		"yinhangka":   true,
+		"yinxingka":   true,
+		"zhongkoukou": true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	sources := map[string]string{}
+	for _, stats := range st.DebugInfos(ctx) {
+		sources[stats.Word] = stats.Source
+	}
+	assert.Equal(t, sources["chongkou"], "重口")
+	assert.Equal(t, sources["yinhangka"], "银行卡")
+	assert.Equal(t, sources["重口"], "")
+
+	// 限制变体数后只保留默认读音
+	st = New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"重口"}, nil
+		},
+		WithPinyinMaxVariants(1),
+	)
+	var words []string
+	for _, stats := range st.DebugInfos(ctx) {
+		words = append(words, stats.Word)
+	}
+	assert.Equal(t, len(words), 2)
+
+	assert.Equal(t, st.RemoveWords(ctx, "重口"), nil)
+	assert.Equal(t, len(st.DebugInfos(ctx)), 0)
+}
+
+func TestPinyinMixedHeteronym(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"重口味"}, nil
+		},
+		WithMode(ModePinyinMixed),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"zhong口味":   true,
+		"chong口wei": true,
+		"重kouwei":   true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+}
+
+func TestHomophone(t *testing.T) {
+	ctx := context.Background()
+	st := New(
+		buildWordsCall,
+		WithMode(ModeHomophone),
+	)
+	for text, hit := range map[string]bool{
+		"你个瞅八怪": true,
+		"丑巴怪":   true,
+		"抽八怪":   true,
+		"丑八":    false,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, lastText, err := st.MatchReplace(ctx, "你个瞅八怪")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "你个***")
+
+	// 区分声调时 抽（chōu）与 丑（chǒu）不再等价
+	st = New(
+		buildWordsCall,
+		WithMode(ModeHomophone),
+		WithHomophoneTone(),
+	)
+	for text, hit := range map[string]bool{
+		"瞅八怪": true,
+		"抽八怪": false,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+}
+
+func TestComboWindow(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"司马南|美国"}, nil
+		},
+		WithComboWindow(10, 1),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"司马南去了美国":          true,
+		"司马南去了很远很远很远很远的美国": false,
+		"司马南在家。美国":         false,
+		"simanan去了meiguo":  true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, lastText, err := st.MatchReplace(ctx, "美国。司马南去了美国")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "美国。***去了**")
+}
+
+func TestOrderedCombo(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"司马南>美国"}, nil
+		},
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"司马南去了美国":         true,
+		"美国人说司马南":         false,
+		"simanan去了meiguo": true,
+		"meiguo人说simanan": false,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	isHit, lastText, err := st.MatchReplace(ctx, "美国人说司马南去了美国")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "美国人说***去了**")
+}
+
+func TestRule(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithBuildEntries(func(ctx context.Context) ([]dfa.Entry, error) {
+			return []dfa.Entry{
+				{Word: "(枪支|弹药) AND 出售 AND NOT 新闻", Rule: true, Name: "违禁品交易"},
+			}, nil
+		}),
+	)
+	ctx := context.Background()
+	isHit, hitWord, err := st.Hit(ctx, "低价出售枪支")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWord, "违禁品交易")
+
+	isHit, _, err = st.Hit(ctx, "新闻：有人出售枪支")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, false)
+
+	isHit, lastText, err := st.MatchReplace(ctx, "低价出售枪支，新款上市")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "低价****，新款上市")
+
+	assert.NotEqual(t, (&sensitiveWord{options: options{
+		logger: zap.S(),
+		buildEntriesCall: func(ctx context.Context) ([]dfa.Entry, error) {
+			return []dfa.Entry{{Word: "枪支 AND", Rule: true}}, nil
+		},
+	}}).buildWords(ctx), nil)
+}
+
+func TestAllowWords(t *testing.T) {
+	st := New(
+		func(ctx context.Context) ([]string, error) {
+			return []string{"sb", "丑八怪"}, nil
+		},
+		WithMode(ModeStats),
+		WithBuildAllowWords(func(ctx context.Context) ([]string, error) {
+			return []string{"sbt", "丑八怪兽"}, nil
+		}),
+	)
+	ctx := context.Background()
+	for text, hit := range map[string]bool{
+		"sbt 数据":     false,
+		"你个sb":       true,
+		"丑八怪兽来了":     false,
+		"你个丑八怪":      true,
+		"choubaguai": true,
+	} {
+		isHit, _, err := st.Hit(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, hit)
+	}
+
+	var suppressed []*dfa.Match
+	isHit, lastText, err := st.MatchReplace(ctx, "丑八怪兽和丑八怪", dfa.WithSuppressed(&suppressed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "丑八怪兽和***")
+	assert.Equal(t, len(suppressed), 1)
+	assert.Equal(t, suppressed[0].Allow, "丑八怪兽")
+
+	for _, stats := range st.DebugInfos(ctx) {
+		switch stats.Word {
+		case "丑八怪兽":
+			assert.Equal(t, stats.Allow, true)
+			assert.Equal(t, stats.HitCount, uint64(2))
+		case "丑八怪":
+			assert.Equal(t, stats.SuppressedCount, uint64(2))
+		}
+	}
+}
+
+func TestCategory(t *testing.T) {
+	buildEntries := func(ctx context.Context) ([]dfa.Entry, error) {
+		return []dfa.Entry{
+			{Word: "司马南|美国", Category: "politics", Severity: 3},
+			{Word: "丑八怪", Category: "abuse", Severity: 2},
+			{Word: "笨蛋", Category: "mild", Severity: 1},
+		}, nil
+	}
+	st := New(
+		nil,
+		WithBuildEntries(buildEntries),
+		WithCategoryMaxGap("abuse", 1),
+	)
+	ctx := context.Background()
+	text := "司马南说美国人是丑了八怪和笨蛋"
+
+	matches, err := st.FindAll(ctx, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(matches), 3)
+	assert.Equal(t, matches[1].Word, "丑八怪")
+	assert.Equal(t, matches[1].Category, "abuse")
+	assert.Equal(t, matches[1].Severity, 2)
+
+	isHit, hitWord, err := st.Hit(ctx, text, dfa.WithCategories("mild"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, hitWord, "笨蛋")
+
+	// 拼音变体沿用原词的分类
+	isHit, lastText, err := st.MatchReplace(ctx, "choubaguai和笨蛋", dfa.WithMinSeverity(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "**********和笨蛋")
+
+	st = New(
+		nil,
+		WithBuildEntries(buildEntries),
+		WithMinSeverity(3),
+	)
+	isHit, lastText, err = st.MatchReplace(ctx, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, isHit, true)
+	assert.Equal(t, lastText, "***说**人是丑了八怪和笨蛋")
+}
+
+func TestReplacement(t *testing.T) {
+	st := New(
+		buildWordsCall,
+		WithBuildEntries(func(ctx context.Context) ([]dfa.Entry, error) {
+			return []dfa.Entry{
+				{Word: "笨蛋", Replacement: "**"},
+				{Word: "他妈的", Replacement: "哎呀"},
+			}, nil
+		}),
+	)
+	ctx := context.Background()
+	for text, want := range map[string]string{
+		"你这个笨蛋":     "你这个**",
+		"他-妈-的，丑八怪": "哎呀，***",
+		"tamade":    "哎呀",
+		"你个bendan":  "你个**",
+	} {
+		isHit, lastText, err := st.MatchReplace(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, lastText, want)
+	}
+}
+
+func TestMasker(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []struct {
+		masker dfa.Masker
+		want   string
+	}{
+		{nil, "你个*-*-*"},
+		{dfa.KeepEndsMasker('*'), "你个丑-*-怪"},
+		{dfa.DeleteMasker(), "你个--"},
+		{dfa.MaskerFunc(func(match *dfa.Match) string {
+			return "[" + match.Word + "]"
+		}), "你个[丑八怪]--"},
+	} {
+		st := New(
+			buildWordsCall,
+			WithMasker(c.masker),
+		)
+		isHit, lastText, err := st.MatchReplace(ctx, "你个丑-八-怪")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, isHit, true)
+		assert.Equal(t, lastText, c.want)
+	}
+}
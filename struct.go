@@ -3,13 +3,24 @@ package sensitive_words
 import (
 	"context"
 	"regexp"
+
+	"github.com/mingolm/sensitive-words/dfa"
 )
 
 type Mode int
 
 const (
-	ModePinyin Mode = iota // 开启拼音匹配
-	ModeStats              // 开启命中统计
+	ModePinyin         Mode = 1 << iota // 开启拼音匹配
+	ModeStats                           // 开启命中统计
+	ModeCaseFold                        // 开启大小写折叠，忽略大小写匹配
+	ModeNFKC                            // 开启 NFKC 及全角/半角归一化
+	ModeTraditional                     // 开启繁简体等价匹配
+	ModeConfusables                     // 开启形近字符（homoglyph/leetspeak）折叠
+	ModeWildcard                        // 开启通配符解析，支持 ?、*{m,n} 与 [票漂]
+	ModeFuzzy                           // 开启拉丁词、拼音词的编辑距离模糊匹配
+	ModePinyinInitials                  // 开启拼音首字母匹配，如 cbg 命中 丑八怪
+	ModePinyinMixed                     // 开启汉字与拼音混合匹配，如 丑ba怪 命中 丑八怪，同时覆盖 ModePinyin 的全拼
+	ModeHomophone                       // 开启同音字匹配，汉字按读音折叠后再匹配，如 瞅八怪 命中 丑八怪
 )
 
 func (t *Mode) Contain(m Mode) bool {
@@ -17,7 +28,7 @@ func (t *Mode) Contain(m Mode) bool {
 }
 
 func (t Mode) Range(fn func(value Mode) error) error {
-	for _, m := range []Mode{ModePinyin, ModeStats} {
+	for _, m := range []Mode{ModePinyin, ModeStats, ModeCaseFold, ModeNFKC, ModeTraditional, ModeConfusables, ModeWildcard, ModeFuzzy, ModePinyinInitials, ModePinyinMixed, ModeHomophone} {
 		if t&m == m {
 			if err := fn(m); err != nil {
 				return err
@@ -30,5 +41,8 @@ func (t Mode) Range(fn func(value Mode) error) error {
 
 type BuildWordsFn func(ctx context.Context) ([]string, error)
 
-// 中文 + |
-var pinyinWordReg = regexp.MustCompile("^\\p{Han}+([|\u00B7\u2022\u2027\u30FB\u002E\u0387\u16EB\u2219\u22C5\uFF65\u05BC]\\p{Han}+)*?$")
+// BuildEntriesFn 返回带匹配规则的敏感词
+type BuildEntriesFn func(ctx context.Context) ([]dfa.Entry, error)
+
+// 中文 + | 或 >
+var pinyinWordReg = regexp.MustCompile("^\\p{Han}+([|>\u00B7\u2022\u2027\u30FB\u002E\u0387\u16EB\u2219\u22C5\uFF65\u05BC]\\p{Han}+)*?$")